@@ -0,0 +1,211 @@
+package trie
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+/* FROZEN COMPRESSED SPARSE ROW IMPLEMENTATION */
+
+// A FrozenPhraseTrie is a compact, read-only PhraseTrie
+//
+// Nodes are numbered breadth first from the root (node 0) and stored in a few
+// flat arrays instead of a pointer tree:
+//
+//	keys[keyOffsets[i]:keyOffsets[i+1]] is the key of node i
+//	childOffsets[i]:childOffsets[i+1] are the node numbers of the children
+//	of node i, sorted by key so they can be binary searched
+//	valueIndices[i] is the index of the value of node i in values,
+//	or -1 if node i does not end a member phrase
+//
+// The structure holds no per node pointers, so it takes far less memory than
+// a PhraseTrie and the garbage collector does not have to scan it.
+// A FrozenPhraseTrie is safe for concurrent use
+type FrozenPhraseTrie[V any] struct {
+	keys         string
+	keyOffsets   []uint32
+	childOffsets []uint32
+	valueIndices []int32
+	values       []V
+	normalizer   Normalizer
+}
+
+// ErrTooLarge is returned when a Trie has too many nodes or too long keys
+// to be frozen, more than 2^31 nodes or 4 GiB of keys
+var ErrTooLarge = errors.New("trie: too large to freeze")
+
+// maxFrozenKeys is the largest total length of the keys of a FrozenPhraseTrie
+var maxFrozenKeys uint64 = math.MaxUint32
+
+// Freeze compiles the current phrases of this Trie into a FrozenPhraseTrie
+// Membership and word normalization follow this Trie, see SetLeafOnly and
+// SetNormalizer. Wildcard phrase words are matched literally
+// Freeze panics with ErrTooLarge if the Trie cannot be frozen
+func (n *PhraseTrie[V]) Freeze() *FrozenPhraseTrie[V] {
+	f, err := n.freeze()
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// freeze is Freeze returning ErrTooLarge instead of overflowing the offsets
+func (n *PhraseTrie[V]) freeze() (*FrozenPhraseTrie[V], error) {
+	var keys strings.Builder
+
+	f := &FrozenPhraseTrie[V]{
+		keyOffsets:   []uint32{0, 0}, // root has the empty key
+		valueIndices: []int32{-1},
+		normalizer:   n.normalizer(),
+	}
+
+	leafOnly := n.config().leafOnly
+
+	nodes := []*PhraseTrie[V]{n}
+	for i := 0; i < len(nodes); i++ {
+		f.childOffsets = append(f.childOffsets, uint32(len(nodes)))
+
+		for _, child := range nodes[i].sortedChildren() {
+			if uint64(keys.Len()+len(child.key)) > maxFrozenKeys || len(nodes) == math.MaxInt32 {
+				return nil, ErrTooLarge
+			}
+
+			keys.WriteString(child.key)
+			f.keyOffsets = append(f.keyOffsets, uint32(keys.Len()))
+
+			if child.endsPhrase(leafOnly) {
+				f.valueIndices = append(f.valueIndices, int32(len(f.values)))
+				f.values = append(f.values, child.value)
+			} else {
+				f.valueIndices = append(f.valueIndices, -1)
+			}
+
+			nodes = append(nodes, child)
+		}
+	}
+
+	f.childOffsets = append(f.childOffsets, uint32(len(nodes)))
+	f.keys = keys.String()
+
+	return f, nil
+}
+
+// Len returns the number of member phrases in this Trie
+func (f *FrozenPhraseTrie[V]) Len() int {
+	return len(f.values)
+}
+
+// IsMember checks if the given phrase is a member of this Trie
+// and returns the phrase value if true
+func (f *FrozenPhraseTrie[V]) IsMember(phrase []string) (bool, V) {
+	var zero V
+
+	phrase = normalizePhrase(f.normalizer, phrase)
+	if len(phrase) == 0 {
+		return false, zero
+	}
+
+	node := 0
+	for _, word := range phrase {
+		if node = f.child(node, word); node == -1 {
+			return false, zero
+		}
+	}
+
+	if f.valueIndices[node] == -1 {
+		return false, zero
+	}
+
+	return true, f.values[f.valueIndices[node]]
+}
+
+// FindMember finds the longest member phrase the given sequence begins with,
+// like PhraseTrie.FindMember
+func (f *FrozenPhraseTrie[V]) FindMember(sequence []string) (bool, []string, V) {
+	var value V
+
+	length, vi := f.longest(normalizeSentence(f.normalizer, sequence))
+	if length != 0 {
+		value = f.values[vi]
+	}
+
+	phrase := make([]string, length)
+	copy(phrase, sequence[:length])
+
+	return length != 0, phrase, value
+}
+
+// FindAllMembers finds the longest member phrase starting at each index of
+// the sentence, like PhraseTrie.FindAllMembers
+func (f *FrozenPhraseTrie[V]) FindAllMembers(sentence []string) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+	words := normalizeSentence(f.normalizer, sentence)
+
+	for i := 0; i < len(words); i++ {
+		if len(f.values) == 0 { // no phrases to match
+			return nil
+		}
+
+		length, vi := f.longest(words[i:])
+		if length != 0 { // valid phrase was found
+			phrase := make([]string, length)
+			copy(phrase, words[i:i+length])
+
+			pc := NewPhraseContext(phrase, words, []int{i, i + length - 1}, f.values[vi])
+			foundMembers = append(foundMembers, restore(f.normalizer, pc, sentence))
+		}
+	}
+
+	return foundMembers
+}
+
+// longest returns the length and value index of the longest member phrase
+// the sequence begins with, 0 if there is none
+func (f *FrozenPhraseTrie[V]) longest(sequence []string) (int, int32) {
+	var (
+		length int
+		vi     int32
+	)
+
+	node := 0
+	for i, word := range sequence {
+		if node = f.child(node, word); node == -1 { // dead end
+			break
+		}
+
+		if f.valueIndices[node] != -1 { // longest phrase so far
+			length = i + 1
+			vi = f.valueIndices[node]
+		}
+	}
+
+	return length, vi
+}
+
+// child binary searches the children of node for the given key
+// Returns the child node number, or -1 if there is none
+func (f *FrozenPhraseTrie[V]) child(node int, key string) int {
+	lo, hi := int(f.childOffsets[node]), int(f.childOffsets[node+1])
+
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+
+		switch k := f.key(mid); {
+		case k == key:
+			return mid
+		case k < key:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return -1
+}
+
+// key returns the key of node i
+func (f *FrozenPhraseTrie[V]) key(i int) string {
+	return f.keys[f.keyOffsets[i]:f.keyOffsets[i+1]]
+}
@@ -0,0 +1,165 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	trie := mockTrieFull()
+	f := trie.Freeze()
+
+	// 1 root + 12 phrase nodes, 9 phrases
+	assert.Equal(t, 14, len(f.keyOffsets))
+	assert.Equal(t, 14, len(f.childOffsets))
+	assert.Equal(t, 13, len(f.valueIndices))
+	assert.Equal(t, 9, f.Len())
+
+	// root children sorted by key
+	assert.Equal(t, "break", f.key(1))
+	assert.Equal(t, "breaking", f.key(2))
+	assert.Equal(t, "double", f.key(3))
+	assert.Equal(t, "r/g", f.key(4))
+	assert.Equal(t, "shooting", f.key(5))
+	assert.Equal(t, -1, f.child(0, "$AAPL"))
+	assert.Equal(t, 5, f.child(0, "shooting"))
+
+	// empty
+	empty := NewPhraseTrie(nil).Freeze()
+	assert.Equal(t, 0, empty.Len())
+	assert.Nil(t, empty.FindAllMembers([]string{"break"}))
+
+	member, _ := empty.IsMember([]string{"break"})
+	assert.False(t, member)
+
+	// keys too long for the offsets
+	defer func(max uint64) { maxFrozenKeys = max }(maxFrozenKeys)
+	maxFrozenKeys = 8
+
+	assert.PanicsWithValue(t, ErrTooLarge, func() { trie.Freeze() })
+}
+
+func TestFrozenIsMember(t *testing.T) {
+	trie := mockTrieFull()
+	trie.Add([]string{"shooting", "up", "fast"}, 10)
+	f := trie.Freeze()
+
+	phrases := []string{
+		"break", "shooting", "break out", "break up", "shooting up", "break out nicely",
+		"r/g", "breaking double bottom", "double bottom", "shooting up fast",
+		"breaking", "breaking double", "break down", "nicely", "$AAPL",
+	}
+
+	for _, p := range phrases {
+		member, value := trie.IsMember(strings.Split(p, " "))
+		fMember, fValue := f.IsMember(strings.Split(p, " "))
+
+		assert.Equal(t, member, fMember, p)
+		assert.Equal(t, value, fValue, p)
+	}
+
+	member, _ := f.IsMember(nil)
+	assert.False(t, member)
+
+	// later changes are not reflected
+	trie.Remove([]string{"r/g"})
+
+	member, value := f.IsMember([]string{"r/g"})
+	assert.True(t, member)
+	assert.Equal(t, 7, value)
+
+	// leaf-only mode
+	trie.SetLeafOnly(true)
+	f = trie.Freeze()
+
+	member, _ = f.IsMember([]string{"break", "out"})
+	assert.False(t, member)
+
+	member, value = f.IsMember([]string{"break", "out", "nicely"})
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+}
+
+func TestFrozenFindMember(t *testing.T) {
+	trie := mockTrieFull()
+	f := trie.Freeze()
+
+	sequences := []string{
+		"break out nicely today",
+		"break out today",
+		"break today",
+		"breaking double today",
+		"$AAPL break out",
+		"r/g",
+	}
+
+	for _, s := range sequences {
+		valid, phrase, value := trie.FindMember(strings.Split(s, " "))
+		fValid, fPhrase, fValue := f.FindMember(strings.Split(s, " "))
+
+		assert.Equal(t, valid, fValid, s)
+		assert.Equal(t, phrase, fPhrase, s)
+		assert.Equal(t, value, fValue, s)
+	}
+}
+
+func TestFrozenFindAllMembers(t *testing.T) {
+	trie := mockTrieFull()
+	f := trie.Freeze()
+
+	sentences := []string{
+		"$AAPL isn't doing anything today",
+		"$AAPL isn't gonna break today",
+		"its shooting up it might even break up i bet $100 $AAPL will break out nicely",
+		"its breaking double bottom $100 $AAPL will break out",
+		"",
+	}
+
+	for _, s := range sentences {
+		sSplit := strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), f.FindAllMembers(sSplit), s)
+	}
+
+	large, message := mockLexiconLarge()
+	assert.Equal(t, large.FindAllMembers(message), large.Freeze().FindAllMembers(message))
+
+	// generic values
+	weights := NewPhraseTrieOf(map[string]float64{"break out": 0.75})
+	found := weights.Freeze().FindAllMembers(strings.Split("will break out", " "))
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, 0.75, found[0].Value)
+}
+
+func BenchmarkFreeze(b *testing.B) {
+	large, _ := mockLexiconLarge()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = large.Freeze()
+	}
+}
+
+func BenchmarkFrozenIsMember(b *testing.B) {
+	f := mockTrieFull().Freeze()
+	p := []string{"break", "out", "nicely"}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = f.IsMember(p)
+	}
+}
+
+func BenchmarkFrozenFindAllMembersLong(b *testing.B) {
+	large, long := mockLexiconLarge()
+	f := large.Freeze()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = f.FindAllMembers(long)
+	}
+}
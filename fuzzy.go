@@ -0,0 +1,307 @@
+package trie
+
+import (
+	"math/bits"
+)
+
+/* APPROXIMATE (FUZZY) MATCHING */
+
+// FindMemberFuzzy is FindMember allowing misspelled and missing or extra words
+// Each phrase word matches a word of the sequence within maxWordEdits
+// character edits (Levenshtein distance), and up to maxTokenEdits phrase
+// words in total can be missing from the sequence or extra sequence words
+// can come between phrase words, e.g. "breakk right out" matches "break out"
+// with 1 word edit and 1 token edit. The first phrase word is never missing
+// Negative budgets are treated as 0
+//
+// The Cost of the match is the sum of its character edits and token edits
+// Returns a PhraseContext of the phrase ending furthest into the sequence,
+// the cheapest on ties, or nil if the sequence does not begin with a match
+// Its Pattern is the stored phrase if it differs from the matched words
+func (n *PhraseTrie[V]) FindMemberFuzzy(sequence []string, maxWordEdits, maxTokenEdits int) *PhraseContextOf[V] {
+	words := n.normalizeSentence(sequence)
+
+	end, positions, pattern, cost := n.longestFuzzy(words, maxWordEdits, maxTokenEdits)
+	if end == nil {
+		return nil
+	}
+
+	pc := newMatchContext(words, 0, end.value, positions, pattern)
+	pc.Cost = cost
+
+	return n.restore(pc, sequence)
+}
+
+// FindAllMembersFuzzy is FindAllMembers with approximate matching,
+// see FindMemberFuzzy
+func (n *PhraseTrie[V]) FindAllMembersFuzzy(sentence []string, maxWordEdits, maxTokenEdits int) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+	words := n.normalizeSentence(sentence)
+
+	for i := 0; i < len(words); i++ {
+		if n.IsLeaf() { // no children to match
+			return nil
+		}
+
+		end, positions, pattern, cost := n.longestFuzzy(words[i:], maxWordEdits, maxTokenEdits)
+		if end != nil { // valid phrase was found
+			pc := newMatchContext(words, i, end.value, positions, pattern)
+			pc.Cost = cost
+
+			foundMembers = append(foundMembers, n.restore(pc, sentence))
+		}
+	}
+
+	return foundMembers
+}
+
+// longestFuzzy returns the end node, matched word positions, stored phrase
+// and cost of the approximate match the sequence begins with that ends
+// furthest into it, the cheapest on ties
+// The end node is nil if there is none, the stored phrase is nil if it
+// equals the matched words
+func (n *PhraseTrie[V]) longestFuzzy(sequence []string, maxWordEdits, maxTokenEdits int) (*PhraseTrie[V], []int, []string, int) {
+	var (
+		end       *PhraseTrie[V]
+		positions []int
+		pattern   []string
+		cost      int
+	)
+
+	n.searchFuzzy(sequence, maxWordEdits, maxTokenEdits, func(node *PhraseTrie[V], p []int, pat []string, c int) {
+		last := p[len(p)-1]
+		if end == nil || last > positions[len(positions)-1] || (last == positions[len(positions)-1] && c < cost) {
+			end = node
+			positions = append(positions[:0], p...)
+			pattern = append(pattern[:0], pat...)
+			cost = c
+		}
+	})
+
+	if end != nil && equalWords(pattern, positions, sequence) {
+		pattern = nil
+	}
+
+	return end, positions, pattern, cost
+}
+
+// searchFuzzy follows the sequence down this Trie, matching every child
+// within maxWordEdits character edits of the next word, and within a
+// budget of maxTokenEdits phrase words left out and sequence words skipped
+// between phrase words. Calls fn with the end node, the positions of the
+// matched words, the stored phrase and the cost of every approximate match
+// the sequence begins with. positions and pattern are only valid until fn returns
+func (n *PhraseTrie[V]) searchFuzzy(sequence []string, maxWordEdits, maxTokenEdits int, fn func(end *PhraseTrie[V], positions []int, pattern []string, cost int)) {
+	maxWordEdits, maxTokenEdits = max(maxWordEdits, 0), max(maxTokenEdits, 0)
+
+	config := n.config()
+
+	span := DefaultWildcardCap
+	if config.wildcardCap != 0 {
+		span = int(config.wildcardCap)
+	}
+
+	var (
+		positions []int
+		pattern   []string
+		words     []fuzzyWord // prepared on first use
+		from      func(node *PhraseTrie[V], pos, tokens, cost int)
+	)
+
+	if maxWordEdits > 0 {
+		words = make([]fuzzyWord, len(sequence))
+	}
+
+	// visit enters child after it matched the words from start up to pos
+	visit := func(child *PhraseTrie[V], start, pos, tokens, cost int) {
+		mark := len(positions)
+		for p := start; p < pos; p++ {
+			positions = append(positions, p)
+		}
+		pattern = append(pattern, child.key)
+
+		if len(positions) != 0 && child.endsPhrase(config.leafOnly) {
+			fn(child, positions, pattern, cost)
+		}
+
+		from(child, pos, tokens, cost)
+
+		positions = positions[:mark]
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	// from follows the sequence from pos down the children of node with
+	// tokens token edits left
+	from = func(node *PhraseTrie[V], pos, tokens, cost int) {
+		skips := tokens
+		if len(positions) == 0 { // the sequence begins with the phrase
+			skips = 0
+		}
+
+		for skip := 0; skip <= skips && pos+skip < len(sequence); skip++ {
+			if maxWordEdits == 0 {
+				if child := node.child(sequence[pos+skip]); child != nil && !isWildcard(child.key) {
+					visit(child, pos+skip, pos+skip+1, tokens-skip, cost+skip)
+				}
+
+				continue
+			}
+
+			word := &words[pos+skip]
+			if word.word == "" {
+				*word = newFuzzyWord(sequence[pos+skip])
+			}
+
+			for _, child := range node.children {
+				if isWildcard(child.key) {
+					continue
+				}
+
+				if edits := word.edits(child.key, maxWordEdits); edits <= maxWordEdits {
+					visit(child, pos+skip, pos+skip+1, tokens-skip, cost+skip+edits)
+				}
+			}
+		}
+
+		if config.wildcards {
+			if child := node.child(WildcardWord); child != nil && pos < len(sequence) {
+				visit(child, pos, pos+1, tokens, cost)
+			}
+
+			if child := node.child(WildcardWords); child != nil {
+				for words := 0; words <= span && pos+words <= len(sequence); words++ {
+					visit(child, pos, pos+words, tokens, cost)
+				}
+			}
+		}
+
+		if tokens > 0 && node != n { // leave out a phrase word, but never the first
+			for _, child := range node.children {
+				visit(child, pos, pos, tokens-1, cost+1)
+			}
+		}
+	}
+
+	from(n, 0, maxTokenEdits, 0)
+}
+
+// equalWords returns true if the phrase is the words of the sequence at the positions
+func equalWords(phrase []string, positions []int, sequence []string) bool {
+	if len(phrase) != len(positions) {
+		return false
+	}
+
+	for i, p := range positions {
+		if phrase[i] != sequence[p] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// editDistance returns the Levenshtein distance in runes between a and b,
+// or limit+1 if it is greater than limit
+func editDistance(a, b string, limit int) int {
+	w := newFuzzyWord(b)
+	return w.edits(a, limit)
+}
+
+// A fuzzyWord is a word prepared for computing its edit distance to many keys
+type fuzzyWord struct {
+	word  string
+	runes int
+	set   uint64 // runes of the word hashed into 64 bits
+}
+
+func newFuzzyWord(word string) fuzzyWord {
+	runes, set := runeStats(word)
+	return fuzzyWord{word, runes, set}
+}
+
+// edits returns the Levenshtein distance in runes between key and this word,
+// or limit+1 if it is greater than limit
+// Keys whose lengths or sets of runes differ by more than limit are rejected
+// before the DP, which only fills the diagonal band of cells within limit
+func (w *fuzzyWord) edits(key string, limit int) int {
+	if key == w.word {
+		return 0
+	}
+
+	if limit <= 0 {
+		return limit + 1
+	}
+
+	// every edit changes the length by at most 1
+	// and removes at most one rune from the set of runes of a word
+	runes, set := runeStats(key)
+	if d := runes - w.runes; d > limit || -d > limit {
+		return limit + 1
+	}
+
+	if bits.OnesCount64(set&^w.set) > limit || bits.OnesCount64(w.set&^set) > limit {
+		return limit + 1
+	}
+
+	ra, rb := []rune(key), []rune(w.word)
+
+	// rows of up to 32 runes stay on the stack
+	var rows [2][33]int
+	prev, curr := rows[0][:], rows[1][:]
+	if len(rb) >= len(rows[0]) {
+		prev, curr = make([]int, len(rb)+1), make([]int, len(rb)+1)
+	}
+
+	for j := 0; j <= len(rb); j++ {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		lo, hi := max(1, i-limit), min(len(rb), i+limit)
+
+		// cells outside the band are over limit
+		if lo == 1 {
+			curr[0] = i
+		} else {
+			curr[lo-1] = limit + 1
+		}
+		if hi < len(rb) {
+			curr[hi+1] = limit + 1
+		}
+
+		best := curr[lo-1]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			best = min(best, curr[j])
+		}
+
+		if best > limit { // every path is already over limit
+			return limit + 1
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return min(prev[len(rb)], limit+1)
+}
+
+// runeStats returns the number of runes in s and a bit set of them
+// hashed into 64 bits
+func runeStats(s string) (int, uint64) {
+	var (
+		runes int
+		set   uint64
+	)
+
+	for _, r := range s {
+		runes++
+		set |= 1 << (uint32(r) % 64)
+	}
+
+	return runes, set
+}
@@ -0,0 +1,212 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("break", "break", 0))
+	assert.Equal(t, 1, editDistance("break", "breakk", 1))
+	assert.Equal(t, 1, editDistance("shooting", "shootin", 2))
+	assert.Equal(t, 2, editDistance("break", "braek", 2))
+	assert.Equal(t, 3, editDistance("kitten", "sitting", 3))
+	assert.Equal(t, 1, editDistance("café", "cafe", 1))
+
+	// over the limit
+	assert.Equal(t, 3, editDistance("kitten", "sitting", 2))
+	assert.Equal(t, 1, editDistance("break", "brake", 0))
+	assert.Equal(t, 2, editDistance("up", "upward", 1))
+
+	// outside the band and longer than the stack rows
+	assert.Equal(t, 2, editDistance("abcdef", "badcfe", 1))
+	assert.Equal(t, 4, editDistance("abcdef", "badcfe", 4))
+	long := strings.Repeat("ab", 20)
+	assert.Equal(t, 1, editDistance(long, long+"c", 1))
+	assert.Equal(t, 2, editDistance(long, "c"+long+"c", 2))
+}
+
+func TestEditDistanceBand(t *testing.T) {
+	// the banded DP agrees with the full DP within the limit
+	full := func(a, b string) int {
+		ra, rb := []rune(a), []rune(b)
+		d := make([][]int, len(ra)+1)
+		for i := range d {
+			d[i] = make([]int, len(rb)+1)
+			d[i][0] = i
+		}
+		for j := range d[0] {
+			d[0][j] = j
+		}
+
+		for i := 1; i <= len(ra); i++ {
+			for j := 1; j <= len(rb); j++ {
+				cost := 1
+				if ra[i-1] == rb[j-1] {
+					cost = 0
+				}
+				d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			}
+		}
+
+		return d[len(ra)][len(rb)]
+	}
+
+	r := rand.New(rand.NewSource(1))
+	word := func() string {
+		w := make([]byte, r.Intn(8))
+		for i := range w {
+			w[i] = byte('a' + r.Intn(3))
+		}
+
+		return string(w)
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b, limit := word(), word(), r.Intn(4)
+		assert.Equal(t, min(full(a, b), limit+1), editDistance(a, b, limit), "%q %q %d", a, b, limit)
+	}
+}
+
+func TestFindMemberFuzzy(t *testing.T) {
+	trie := mockTrieFull()
+
+	// misspelled words
+	pc := trie.FindMemberFuzzy(strings.Split("shootin up", " "), 1, 0)
+	assert.Equal(t, []string{"shootin", "up"}, pc.Phrase)
+	assert.Equal(t, []string{"shooting", "up"}, pc.Pattern)
+	assert.Equal(t, []int{0, 1}, pc.Positions)
+	assert.Equal(t, 5, pc.Value)
+	assert.Equal(t, 1, pc.Cost)
+
+	pc = trie.FindMemberFuzzy(strings.Split("breakk out", " "), 1, 0)
+	assert.Equal(t, []string{"break", "out"}, pc.Pattern)
+	assert.Equal(t, 3, pc.Value)
+	assert.Equal(t, 1, pc.Cost)
+
+	assert.Nil(t, trie.FindMemberFuzzy(strings.Split("breakkk out", " "), 1, 0))
+
+	// extra word
+	pc = trie.FindMemberFuzzy(strings.Split("break right out", " "), 0, 1)
+	assert.Equal(t, []string{"break", "out"}, pc.Phrase)
+	assert.Nil(t, pc.Pattern)
+	assert.Equal(t, []int{0, 2}, pc.Positions)
+	assert.Equal(t, 1, pc.Cost)
+
+	// missing word, but never the first
+	pc = trie.FindMemberFuzzy(strings.Split("break nicely", " "), 0, 1)
+	assert.Equal(t, []string{"break", "nicely"}, pc.Phrase)
+	assert.Equal(t, []string{"break", "out", "nicely"}, pc.Pattern)
+	assert.Equal(t, []int{0, 1}, pc.Positions)
+	assert.Equal(t, 6, pc.Value)
+	assert.Equal(t, 1, pc.Cost)
+
+	assert.Nil(t, trie.FindMemberFuzzy(strings.Split("out nicely", " "), 0, 1))
+
+	// exact match is cheapest
+	pc = trie.FindMemberFuzzy(strings.Split("break out today", " "), 1, 1)
+	assert.Equal(t, []string{"break", "out"}, pc.Phrase)
+	assert.Nil(t, pc.Pattern)
+	assert.Equal(t, 3, pc.Value)
+	assert.Equal(t, 0, pc.Cost)
+
+	// no edits is FindMember
+	pc = trie.FindMemberFuzzy(strings.Split("break out nicely", " "), 0, 0)
+	assert.Equal(t, []string{"break", "out", "nicely"}, pc.Phrase)
+	assert.Equal(t, 0, pc.Cost)
+	assert.Nil(t, trie.FindMemberFuzzy(strings.Split("breakk out", " "), 0, 0))
+
+	// negative budgets are 0
+	pc = trie.FindMemberFuzzy([]string{"break", "out"}, 0, -1)
+	assert.Equal(t, []string{"break", "out"}, pc.Phrase)
+	assert.Equal(t, 3, pc.Value)
+	pc = trie.FindMemberFuzzy([]string{"break", "out"}, -1, -1)
+	assert.Equal(t, 3, pc.Value)
+}
+
+func TestFindAllMembersFuzzy(t *testing.T) {
+	trie := mockTrieFull()
+	sentence := strings.Split("its shootin up and will breakk out nicley", " ")
+
+	phrases := trie.FindAllMembersFuzzy(sentence, 2, 0)
+	assert.Equal(t, 2, len(phrases))
+
+	assert.Equal(t, []string{"shootin", "up"}, phrases[0].Phrase)
+	assert.Equal(t, []int{1, 2}, phrases[0].Indices)
+	assert.Equal(t, 5, phrases[0].Value)
+	assert.Equal(t, 1, phrases[0].Cost)
+
+	assert.Equal(t, []string{"breakk", "out", "nicley"}, phrases[1].Phrase)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrases[1].Pattern)
+	assert.Equal(t, []int{5, 7}, phrases[1].Indices)
+	assert.Equal(t, []int{5, 6, 7}, phrases[1].Positions)
+	assert.Equal(t, 6, phrases[1].Value)
+	assert.Equal(t, 3, phrases[1].Cost)
+	assert.Equal(t, sentence, phrases[1].Sentence)
+
+	assert.Nil(t, NewPhraseTrie(nil).FindAllMembersFuzzy(sentence, 1, 1))
+}
+
+func TestFindAllMembersFuzzyWildcards(t *testing.T) {
+	trie := mockTrieWildcards()
+
+	phrases := trie.FindAllMembersFuzzy(strings.Split("will braek the resistance", " "), 2, 0)
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, []string{"braek", "the", "resistance"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"break", "*", "resistance"}, phrases[0].Pattern)
+	assert.Equal(t, []int{1, 3}, phrases[0].Indices)
+	assert.Equal(t, 2, phrases[0].Cost)
+}
+
+func BenchmarkFindAllMembersFuzzy(b *testing.B) {
+	trie := mockTrieFull()
+	sSplit := strings.Split("its shootin up it might even break right up i bet $100 $AAPL will breakk out nicley", " ")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.FindAllMembersFuzzy(sSplit, 1, 1)
+	}
+}
+
+// mockTrieHighFanout returns a Trie of 50k two word phrases, each starting
+// with a different word, and a tweet of lowercase words
+func mockTrieHighFanout() (*PhraseTrieNode, []string) {
+	r := rand.New(rand.NewSource(42))
+	word := func() string {
+		w := make([]byte, 3+r.Intn(8))
+		for i := range w {
+			w[i] = byte('a' + r.Intn(26))
+		}
+
+		return string(w)
+	}
+
+	trie := NewPhraseTrie(nil)
+	for len(trie.children) < 50000 {
+		trie.Add([]string{word(), word()}, 1)
+	}
+
+	tweet := make([]string, 15)
+	for i := range tweet {
+		tweet[i] = word()
+	}
+
+	return trie, tweet
+}
+
+func BenchmarkFindAllMembersFuzzyHighFanout(b *testing.B) {
+	trie, tweet := mockTrieHighFanout()
+
+	for _, edits := range [][2]int{{0, 0}, {1, 0}, {1, 1}} {
+		b.Run(fmt.Sprintf("%d,%d", edits[0], edits[1]), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = trie.FindAllMembersFuzzy(tweet, edits[0], edits[1])
+			}
+		})
+	}
+}
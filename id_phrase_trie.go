@@ -0,0 +1,222 @@
+package trie
+
+import (
+	"slices"
+)
+
+/* TOKEN ID KEYED IMPLEMENTATION */
+
+// An IDPhraseTrie is a PhraseTrie keyed on interned TokenIDs instead of strings
+// Every phrase word is interned in the trie's Vocabulary, so nodes store a
+// 4 byte id instead of a string and matching compares integers
+//
+// Sentences are interned once with InternSentence and can then be matched
+// with IsMember and FindAllMembers without any string compares
+type IDPhraseTrie[V any] struct {
+	vocab      *Vocabulary
+	root       idPhraseTrieNode[V]
+	normalizer Normalizer
+}
+
+// idPhraseTrieNode is an IDPhraseTrie element that stores its key/value pair,
+// whether it terminates a member phrase, and a list of children nodes
+// Indexes its children like PhraseTrie once it passes childIndexThreshold
+type idPhraseTrieNode[V any] struct {
+	key      TokenID
+	value    V
+	terminal bool
+	children []*idPhraseTrieNode[V]
+	index    map[TokenID]*idPhraseTrieNode[V]
+}
+
+// NewIDPhraseTrie creates a new empty IDPhraseTrie that interns phrase words
+// in the given Vocabulary, or a new Vocabulary if nil
+func NewIDPhraseTrie[V any](vocab *Vocabulary) *IDPhraseTrie[V] {
+	if vocab == nil {
+		vocab = NewVocabulary()
+	}
+
+	return &IDPhraseTrie[V]{vocab: vocab}
+}
+
+// Intern builds an IDPhraseTrie from the current phrases of this Trie,
+// interning every phrase word in the given Vocabulary, or a new Vocabulary if nil
+// Membership and word normalization follow this Trie, see SetLeafOnly and
+// SetNormalizer
+func (n *PhraseTrie[V]) Intern(vocab *Vocabulary) *IDPhraseTrie[V] {
+	t := NewIDPhraseTrie[V](vocab)
+	t.normalizer = n.normalizer()
+	t.root.intern(n, n.config().leafOnly, t.vocab)
+
+	return t
+}
+
+// intern recursively copies the children of a PhraseTrie node under this node
+func (in *idPhraseTrieNode[V]) intern(n *PhraseTrie[V], leafOnly bool, vocab *Vocabulary) {
+	for _, child := range n.children {
+		c := &idPhraseTrieNode[V]{key: vocab.Intern(child.key)}
+		if child.endsPhrase(leafOnly) {
+			c.value = child.value
+			c.terminal = true
+		}
+
+		c.intern(child, leafOnly, vocab)
+		in.addChild(c)
+	}
+}
+
+// Vocabulary returns the Vocabulary phrase words are interned in
+func (t *IDPhraseTrie[V]) Vocabulary() *Vocabulary {
+	return t.vocab
+}
+
+// Add interns the phrase words and adds the phrase key/value to this Trie
+// Note: if the phrase already exists in the Trie its value is kept
+func (t *IDPhraseTrie[V]) Add(phrase []string, value V) {
+	phrase = normalizePhrase(t.normalizer, phrase)
+	if len(phrase) == 0 {
+		return
+	}
+
+	node := &t.root
+	for _, word := range phrase {
+		id := t.vocab.Intern(word)
+
+		child := node.child(id)
+		if child == nil { // add new node
+			child = &idPhraseTrieNode[V]{key: id}
+			node.addChild(child)
+		}
+
+		node = child
+	}
+
+	if !node.terminal { // new phrase, set value
+		node.value = value
+		node.terminal = true
+	}
+}
+
+// InternSentence looks up every word of the sentence in the Vocabulary of
+// this Trie, like Vocabulary.InternSentence, after normalizing the words
+// with the Normalizer this Trie was interned with
+// Words holds the original sentence
+func (t *IDPhraseTrie[V]) InternSentence(sentence []string) *InternedSentence {
+	interned := t.vocab.InternSentence(normalizeSentence(t.normalizer, sentence))
+	interned.Words = sentence
+
+	return interned
+}
+
+// IsMember checks if the given interned phrase is a member of this Trie
+// and returns the phrase value if true
+func (t *IDPhraseTrie[V]) IsMember(phrase []TokenID) (bool, V) {
+	var zero V
+
+	if len(phrase) == 0 {
+		return false, zero
+	}
+
+	node := &t.root
+	for _, id := range phrase {
+		if node = node.child(id); node == nil {
+			return false, zero
+		}
+	}
+
+	if !node.terminal {
+		return false, zero
+	}
+
+	return true, node.value
+}
+
+// FindAllMembers finds the longest member phrase starting at each index of
+// the interned sentence, like PhraseTrie.FindAllMembers
+// The phrases and sentence of the returned contexts are the sentence Words,
+// with the stored phrase in Pattern if it differs
+func (t *IDPhraseTrie[V]) FindAllMembers(sentence *InternedSentence) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+
+	for i := range sentence.IDs {
+		var (
+			length int
+			value  V
+		)
+
+		node := &t.root
+		for j, id := range sentence.IDs[i:] {
+			if node = node.child(id); node == nil { // dead end
+				break
+			}
+
+			if node.terminal { // longest phrase so far
+				length = j + 1
+				value = node.value
+			}
+		}
+
+		if length != 0 { // valid phrase was found
+			phrase := make([]string, length)
+			copy(phrase, sentence.Words[i:i+length])
+
+			pc := NewPhraseContext(phrase, sentence.Words, []int{i, i + length - 1}, value)
+			if t.normalizer != nil {
+				pc.Pattern = t.pattern(sentence.IDs[i:i+length], phrase)
+			}
+
+			foundMembers = append(foundMembers, pc)
+		}
+	}
+
+	return foundMembers
+}
+
+// pattern returns the stored phrase of the interned words,
+// or nil if it equals the original phrase
+func (t *IDPhraseTrie[V]) pattern(ids []TokenID, phrase []string) []string {
+	pattern := make([]string, len(ids))
+	for i, id := range ids {
+		pattern[i] = t.vocab.Word(id)
+	}
+
+	if slices.Equal(pattern, phrase) {
+		return nil
+	}
+
+	return pattern
+}
+
+// child returns the child node with the given key, or nil if there is none
+func (in *idPhraseTrieNode[V]) child(key TokenID) *idPhraseTrieNode[V] {
+	if key == UnknownToken {
+		return nil
+	}
+
+	if in.index != nil {
+		return in.index[key]
+	}
+
+	for _, child := range in.children {
+		if child.key == key {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// addChild appends a child node, indexing the children once
+// this node passes childIndexThreshold
+func (in *idPhraseTrieNode[V]) addChild(child *idPhraseTrieNode[V]) {
+	in.children = append(in.children, child)
+
+	if in.index != nil {
+		in.index[child.key] = child
+	} else if len(in.children) > childIndexThreshold {
+		in.index = make(map[TokenID]*idPhraseTrieNode[V], len(in.children))
+		for _, c := range in.children {
+			in.index[c.key] = c
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDPhraseTrieAdd(t *testing.T) {
+	trie := NewIDPhraseTrie[int](nil)
+	vocab := trie.Vocabulary()
+
+	trie.Add([]string{"break", "out"}, 3)
+	trie.Add([]string{"break", "out", "nicely"}, 6)
+	trie.Add([]string{"shooting", "up"}, 5)
+	trie.Add([]string{"break", "out"}, 30)
+	trie.Add(nil, 1)
+
+	assert.Equal(t, 5, vocab.Len())
+
+	member, value := trie.IsMember([]TokenID{vocab.ID("break"), vocab.ID("out")})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, value = trie.IsMember(vocab.InternSentence([]string{"break", "out", "nicely"}).IDs)
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+
+	// prefix
+	member, _ = trie.IsMember([]TokenID{vocab.ID("break")})
+	assert.False(t, member)
+
+	// unknown words
+	member, _ = trie.IsMember(vocab.InternSentence([]string{"break", "down"}).IDs)
+	assert.False(t, member)
+
+	member, _ = trie.IsMember(nil)
+	assert.False(t, member)
+}
+
+func TestIntern(t *testing.T) {
+	trie := mockTrieFull()
+	idTrie := trie.Intern(nil)
+	vocab := idTrie.Vocabulary()
+
+	// every phrase word interned once
+	assert.Equal(t, 9, vocab.Len())
+
+	for _, p := range []string{"break", "break out", "break out nicely", "r/g", "double bottom"} {
+		phrase := strings.Split(p, " ")
+		member, value := trie.IsMember(phrase)
+		idMember, idValue := idTrie.IsMember(vocab.InternSentence(phrase).IDs)
+
+		assert.True(t, idMember, p)
+		assert.Equal(t, member, idMember, p)
+		assert.Equal(t, value, idValue, p)
+	}
+
+	sentences := []string{
+		"$AAPL isn't doing anything today",
+		"$AAPL isn't gonna break today",
+		"its shooting up it might even break up i bet $100 $AAPL will break out nicely",
+		"its breaking double bottom $100 $AAPL will break out",
+	}
+
+	for _, s := range sentences {
+		sSplit := strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), idTrie.FindAllMembers(vocab.InternSentence(sSplit)), s)
+	}
+
+	// shared vocabulary
+	vocab = NewVocabulary()
+	vocab.Intern("$AAPL")
+	idTrie = trie.Intern(vocab)
+	assert.Equal(t, TokenID(1), vocab.ID("$AAPL"))
+	assert.Equal(t, 10, vocab.Len())
+
+	// leaf-only mode
+	trie.SetLeafOnly(true)
+	idTrie = trie.Intern(nil)
+	vocab = idTrie.Vocabulary()
+
+	member, _ := idTrie.IsMember(vocab.InternSentence([]string{"break", "out"}).IDs)
+	assert.False(t, member)
+
+	member, value := idTrie.IsMember(vocab.InternSentence([]string{"break", "out", "nicely"}).IDs)
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+}
+
+func TestIDPhraseTrieFindAllMembers(t *testing.T) {
+	large, message := mockLexiconLarge()
+	idTrie := large.Intern(nil)
+
+	assert.Equal(t, large.FindAllMembers(message), idTrie.FindAllMembers(idTrie.Vocabulary().InternSentence(message)))
+
+	// indexed root
+	assert.NotNil(t, idTrie.root.index)
+
+	// empty
+	empty := NewIDPhraseTrie[int](nil)
+	assert.Equal(t, 0, len(empty.FindAllMembers(empty.Vocabulary().InternSentence(message))))
+}
+
+func BenchmarkIDPhraseTrieIsMember(b *testing.B) {
+	idTrie := mockTrieFull().Intern(nil)
+	p := idTrie.Vocabulary().InternSentence([]string{"break", "out", "nicely"}).IDs
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = idTrie.IsMember(p)
+	}
+}
+
+func BenchmarkIDPhraseTrieFindAllMembersLong(b *testing.B) {
+	large, message := mockLexiconLarge()
+	idTrie := large.Intern(nil)
+	s := idTrie.Vocabulary().InternSentence(message)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = idTrie.FindAllMembers(s)
+	}
+}
@@ -0,0 +1,383 @@
+package trie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A LexiconFormat is a file format LoadLexicon can read phrases from
+//
+// In every format blank lines and comment lines starting with # followed by
+// whitespace are skipped, so hashtag phrases like #bullish are not comments.
+// Phrases are split into words with a SocialTokenizer, or the Tokenizer
+// given to LoadLexiconWith
+type LexiconFormat int
+
+const (
+	// FormatTSV is one phrase<TAB>value pair per line
+	FormatTSV LexiconFormat = iota
+
+	// FormatCSV is comma separated values with a header line naming a
+	// phrase and a value column, in any order. Other columns are ignored
+	FormatCSV
+
+	// FormatJSON is an array of objects with a phrase and a value, a single
+	// object mapping phrases to values, or one phrase and value object per
+	// line. Comments are only allowed in the one object per line form, e.g.
+	//	[{"phrase": "break out", "value": 3}, {"phrase": "short", "value": -2}]
+	//	{"break out": 3, "short": -2}
+	//	{"phrase": "break out", "value": 3}
+	FormatJSON
+)
+
+// String returns the name of this LexiconFormat
+func (f LexiconFormat) String() string {
+	switch f {
+	case FormatTSV:
+		return "TSV"
+	case FormatCSV:
+		return "CSV"
+	case FormatJSON:
+		return "JSON"
+	default:
+		return fmt.Sprintf("LexiconFormat(%d)", int(f))
+	}
+}
+
+// A LexiconError is an error loading a lexicon, with the line it occurred on
+type LexiconError struct {
+	Line int
+	Err  error
+}
+
+func (e *LexiconError) Error() string {
+	return fmt.Sprintf("trie: lexicon line %d: %v", e.Line, e.Err)
+}
+
+func (e *LexiconError) Unwrap() error {
+	return e.Err
+}
+
+// LoadLexicon reads a lexicon of phrases with int values from r in the given
+// format and adds them all to a new Trie
+// If a phrase appears more than once the first value is kept
+func LoadLexicon(r io.Reader, format LexiconFormat) (*PhraseTrieNode, error) {
+	return LoadLexiconOf(r, format, strconv.Atoi)
+}
+
+// LoadLexiconOf is the generic form of LoadLexicon for any phrase value type
+// TSV and CSV values are converted with parse, JSON values are decoded
+// with encoding/json directly into the value type
+func LoadLexiconOf[V any](r io.Reader, format LexiconFormat, parse func(string) (V, error)) (*PhraseTrie[V], error) {
+	return LoadLexiconWith(r, format, parse, nil)
+}
+
+// LoadLexiconWith is LoadLexiconOf splitting the phrases into words with the
+// given Tokenizer, which FindAllMembersText then splits text with too, see
+// SetTokenizer. A nil Tokenizer is the default SocialTokenizer
+func LoadLexiconWith[V any](r io.Reader, format LexiconFormat, parse func(string) (V, error), tokenizer Tokenizer) (*PhraseTrie[V], error) {
+	root := NewPhraseTrieWith[V](nil, tokenizer)
+
+	add := func(line int, phrase string, value V) error {
+		words := root.tokenizer().Tokenize(phrase)
+		if len(words) == 0 {
+			return &LexiconError{line, errors.New("empty phrase")}
+		}
+
+		root.Add(words, value)
+
+		return nil
+	}
+
+	var err error
+
+	switch format {
+	case FormatTSV:
+		err = loadTSV(r, parse, add)
+	case FormatCSV:
+		err = loadCSV(r, parse, add)
+	case FormatJSON:
+		err = loadJSON(r, add)
+	default:
+		err = fmt.Errorf("trie: unknown lexicon format %v", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// isLexiconComment returns true if the line is blank or a comment
+func isLexiconComment(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return line == "" || line == "#" || (strings.HasPrefix(line, "#") && unicode.IsSpace(rune(line[1])))
+}
+
+func loadTSV[V any](r io.Reader, parse func(string) (V, error), add func(int, string, V) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		if isLexiconComment(text) {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimRight(text, "\r"), "\t")
+		if len(fields) != 2 {
+			return &LexiconError{line, fmt.Errorf("expected phrase<TAB>value, got %d fields", len(fields))}
+		}
+
+		value, err := parse(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return &LexiconError{line, err}
+		}
+
+		if err := add(line, fields[0], value); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func loadCSV[V any](r io.Reader, parse func(string) (V, error), add func(int, string, V) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// csv errors already carry their line number
+	header, err := readCSVRecord(reader)
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	phraseCol, valueCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "phrase":
+			phraseCol = i
+		case "value":
+			valueCol = i
+		}
+	}
+
+	line, _ := reader.FieldPos(0)
+	if phraseCol == -1 || valueCol == -1 {
+		return &LexiconError{line, errors.New("header must name a phrase and a value column")}
+	}
+
+	for {
+		record, err := readCSVRecord(reader)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line, _ = reader.FieldPos(0)
+		if len(record) <= phraseCol || len(record) <= valueCol {
+			return &LexiconError{line, fmt.Errorf("expected %d fields, got %d", len(header), len(record))}
+		}
+
+		value, err := parse(strings.TrimSpace(record[valueCol]))
+		if err != nil {
+			return &LexiconError{line, err}
+		}
+
+		if err := add(line, record[phraseCol], value); err != nil {
+			return err
+		}
+	}
+}
+
+// readCSVRecord reads the next record that is not a comment
+// csv.Reader skips blank lines itself, and a record is only a comment if
+// its line starts with #, so rows with an empty first column are kept
+func readCSVRecord(reader *csv.Reader) ([]string, error) {
+	for {
+		record, err := reader.Read()
+		if err != nil || !strings.HasPrefix(record[0], "#") || !isLexiconComment(record[0]) {
+			return record, err
+		}
+	}
+}
+
+// jsonEntry is a phrase and value object of a JSON lexicon
+type jsonEntry[V any] struct {
+	Phrase *string
+	Value  *V
+}
+
+func loadJSON[V any](r io.Reader, add func(int, string, V) error) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	start := bytes.TrimLeft(data, " \t\r\n")
+	switch {
+	case len(start) == 0:
+		return nil
+	case start[0] == '[':
+		return loadJSONArray(data, add)
+	case start[0] == '{' && !isJSONEntry(start):
+		return loadJSONObject(data, add)
+	default:
+		return loadJSONLines(bytes.NewReader(data), add)
+	}
+}
+
+// isJSONEntry returns true if the first JSON value in data is an object with
+// a phrase and a value rather than a mapping of phrases to values
+// Invalid JSON is treated as an entry so the line by line loader reports it
+func isJSONEntry(data []byte) bool {
+	var object map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&object); err != nil {
+		return true
+	}
+
+	var phrase, value bool
+	for key := range object {
+		phrase = phrase || strings.EqualFold(key, "phrase")
+		value = value || strings.EqualFold(key, "value")
+	}
+
+	return phrase && value
+}
+
+// loadJSONArray loads an array of phrase and value objects
+func loadJSONArray[V any](data []byte, add func(int, string, V) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return jsonLexiconError(1, err)
+	}
+
+	for dec.More() {
+		line := jsonLine(data, dec.InputOffset())
+
+		var entry jsonEntry[V]
+		if err := dec.Decode(&entry); err != nil {
+			return jsonLexiconError(line, err)
+		}
+
+		if entry.Phrase == nil || entry.Value == nil {
+			return &LexiconError{line, errors.New("expected an object with a phrase and a value")}
+		}
+
+		if err := add(line, *entry.Phrase, *entry.Value); err != nil {
+			return err
+		}
+	}
+
+	return endJSON(data, dec)
+}
+
+// loadJSONObject loads a single object mapping phrases to values
+func loadJSONObject[V any](data []byte, add func(int, string, V) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return jsonLexiconError(1, err)
+	}
+
+	for dec.More() {
+		line := jsonLine(data, dec.InputOffset())
+
+		key, err := dec.Token()
+		if err != nil {
+			return jsonLexiconError(line, err)
+		}
+
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return jsonLexiconError(line, err)
+		}
+
+		if err := add(line, key.(string), value); err != nil {
+			return err
+		}
+	}
+
+	return endJSON(data, dec)
+}
+
+// endJSON reads the closing delimiter of an array or object lexicon and
+// checks nothing but whitespace follows it
+func endJSON(data []byte, dec *json.Decoder) error {
+	if _, err := dec.Token(); err != nil {
+		return jsonLexiconError(jsonLine(data, dec.InputOffset()), err)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return &LexiconError{jsonLine(data, dec.InputOffset()), errors.New("unexpected data after lexicon")}
+	}
+
+	return nil
+}
+
+// jsonLexiconError wraps a decoding error with the line it occurred on
+func jsonLexiconError(line int, err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return &LexiconError{line, err}
+}
+
+// jsonLine returns the line of the first JSON value at or after offset
+func jsonLine(data []byte, offset int64) int {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+
+	offset = min(offset, int64(len(data)))
+
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// loadJSONLines loads one phrase and value object per line
+func loadJSONLines[V any](r io.Reader, add func(int, string, V) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		if isLexiconComment(text) {
+			continue
+		}
+
+		var entry jsonEntry[V]
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return &LexiconError{line, err}
+		}
+
+		if entry.Phrase == nil || entry.Value == nil {
+			return &LexiconError{line, errors.New("expected an object with a phrase and a value")}
+		}
+
+		if err := add(line, *entry.Phrase, *entry.Value); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
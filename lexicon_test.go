@@ -0,0 +1,260 @@
+package trie
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLexiconTSV(t *testing.T) {
+	tsv := `# sentiment lexicon
+break	1
+break out	3
+
+  # nested phrases
+break  out nicely	6
+#bullish	8
+shooting up	 5
+break	10
+`
+	trie, err := LoadLexicon(strings.NewReader(tsv), FormatTSV)
+	assert.Nil(t, err)
+
+	for p, v := range map[string]int{"break": 1, "break out": 3, "break out nicely": 6, "#bullish": 8, "shooting up": 5} {
+		member, value := trie.IsMember(strings.Fields(p))
+		assert.True(t, member, p)
+		assert.Equal(t, v, value, p)
+	}
+
+	member, _ := trie.IsMember([]string{"#"})
+	assert.False(t, member)
+
+	// errors with line numbers
+	_, err = LoadLexicon(strings.NewReader("break\t1\n\nbreak out\n"), FormatTSV)
+	assert.EqualError(t, err, "trie: lexicon line 3: expected phrase<TAB>value, got 1 fields")
+
+	_, err = LoadLexicon(strings.NewReader("# values\nbreak\tup\n"), FormatTSV)
+	var lexErr *LexiconError
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 2, lexErr.Line)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+
+	_, err = LoadLexicon(strings.NewReader("  \t1\n"), FormatTSV)
+	assert.EqualError(t, err, "trie: lexicon line 1: empty phrase")
+
+	// empty
+	trie, err = LoadLexicon(strings.NewReader(""), FormatTSV)
+	assert.Nil(t, err)
+	assert.True(t, trie.IsLeaf())
+}
+
+func TestLoadLexiconCSV(t *testing.T) {
+	csv := `# exported from the analyst sheet
+Analyst,Value,Phrase
+jb,3,break out
+
+jb, 6 ,"break out nicely"
+# comment, with commas
+kt,-2,"double, bottom"
+`
+	trie, err := LoadLexicon(strings.NewReader(csv), FormatCSV)
+	assert.Nil(t, err)
+
+	member, value := trie.IsMember([]string{"break", "out", "nicely"})
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+
+	// phrases are tokenized
+	member, value = trie.IsMember([]string{"double", ",", "bottom"})
+	assert.True(t, member)
+	assert.Equal(t, -2, value)
+
+	// an empty first column is data, not a comment
+	trie, err = LoadLexicon(strings.NewReader("category,phrase,value\n,break out,3\n  ,short,-2\n"), FormatCSV)
+	assert.Nil(t, err)
+
+	member, value = trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, value = trie.IsMember([]string{"short"})
+	assert.True(t, member)
+	assert.Equal(t, -2, value)
+
+	// missing header column
+	_, err = LoadLexicon(strings.NewReader("\n# header\nphrase,score\nbreak,1\n"), FormatCSV)
+	assert.EqualError(t, err, "trie: lexicon line 3: header must name a phrase and a value column")
+
+	// bad rows
+	_, err = LoadLexicon(strings.NewReader("phrase,value\nbreak,1\nbreak out\n"), FormatCSV)
+	assert.EqualError(t, err, "trie: lexicon line 3: expected 2 fields, got 1")
+
+	_, err = LoadLexicon(strings.NewReader("phrase,value\nbreak,1\n\nbreak out,x\n"), FormatCSV)
+	var lexErr *LexiconError
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 4, lexErr.Line)
+
+	// csv syntax errors carry their own line
+	_, err = LoadLexicon(strings.NewReader("phrase,value\n\"break,1\n"), FormatCSV)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestLoadLexiconJSON(t *testing.T) {
+	json := `# one object per line
+{"phrase": "break out", "value": 3}
+{"value": 5, "phrase": "shooting  up"}
+
+{"phrase": "#bullish", "value": 8}
+`
+	trie, err := LoadLexicon(strings.NewReader(json), FormatJSON)
+	assert.Nil(t, err)
+
+	member, value := trie.IsMember([]string{"shooting", "up"})
+	assert.True(t, member)
+	assert.Equal(t, 5, value)
+
+	member, value = trie.IsMember([]string{"#bullish"})
+	assert.True(t, member)
+	assert.Equal(t, 8, value)
+
+	// errors
+	_, err = LoadLexicon(strings.NewReader("{\"phrase\": \"break\", \"value\": 1}\n{\"phrase\": \"up\"}\n"), FormatJSON)
+	assert.EqualError(t, err, "trie: lexicon line 2: expected an object with a phrase and a value")
+
+	_, err = LoadLexicon(strings.NewReader("\n{\"phrase\": \"break\", \"value\": 1.5}\n"), FormatJSON)
+	var lexErr *LexiconError
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 2, lexErr.Line)
+
+	_, err = LoadLexicon(strings.NewReader("{\"phrase\": \"break\""), FormatJSON)
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 1, lexErr.Line)
+}
+
+func TestLoadLexiconJSONArray(t *testing.T) {
+	json := `[
+	{"phrase": "break out", "value": 3},
+	{
+		"phrase": "short",
+		"value": -2
+	}
+]
+`
+	trie, err := LoadLexicon(strings.NewReader(json), FormatJSON)
+	assert.Nil(t, err)
+
+	member, value := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, value = trie.IsMember([]string{"short"})
+	assert.True(t, member)
+	assert.Equal(t, -2, value)
+
+	// errors
+	var lexErr *LexiconError
+	_, err = LoadLexicon(strings.NewReader("[\n{\"phrase\": \"break\", \"value\": 1},\n{\"phrase\": \"up\"}\n]"), FormatJSON)
+	assert.EqualError(t, err, "trie: lexicon line 3: expected an object with a phrase and a value")
+
+	_, err = LoadLexicon(strings.NewReader("[\n{\"phrase\": \"break\", \"value\": 1},\n\n{\"phrase\": \"up\", \"value\": 1.5}]"), FormatJSON)
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 4, lexErr.Line)
+
+	_, err = LoadLexicon(strings.NewReader("[\n{\"phrase\": \"break\", \"value\": 1},\n{\"phrase\": \"up\""), FormatJSON)
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 3, lexErr.Line)
+
+	_, err = LoadLexicon(strings.NewReader("[{\"phrase\": \"break\", \"value\": 1}]\n[]"), FormatJSON)
+	assert.EqualError(t, err, "trie: lexicon line 2: unexpected data after lexicon")
+}
+
+func TestLoadLexiconJSONObject(t *testing.T) {
+	json := `{
+	"break out": 3,
+	"short": -2,
+	"break out": 5
+}
+`
+	trie, err := LoadLexicon(strings.NewReader(json), FormatJSON)
+	assert.Nil(t, err)
+
+	// the first value is kept
+	member, value := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, value = trie.IsMember([]string{"short"})
+	assert.True(t, member)
+	assert.Equal(t, -2, value)
+
+	// a single phrase
+	trie, err = LoadLexicon(strings.NewReader(`{"break out": 3}`), FormatJSON)
+	assert.Nil(t, err)
+
+	member, value = trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	// errors
+	var lexErr *LexiconError
+	_, err = LoadLexicon(strings.NewReader("{\n\"break out\": 3,\n\"short\": \"x\"\n}"), FormatJSON)
+	assert.True(t, errors.As(err, &lexErr))
+	assert.Equal(t, 3, lexErr.Line)
+
+	_, err = LoadLexicon(strings.NewReader("{\n\"break out\": 3,\n\"\": 1\n}"), FormatJSON)
+	assert.EqualError(t, err, "trie: lexicon line 3: empty phrase")
+}
+
+func TestLoadLexiconOf(t *testing.T) {
+	parseFloat := func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	weights, err := LoadLexiconOf(strings.NewReader("break out\t0.75\n"), FormatTSV, parseFloat)
+	assert.Nil(t, err)
+
+	member, weight := weights.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 0.75, weight)
+
+	// json values decode into the value type
+	type sentiment struct {
+		Polarity   int
+		Confidence float64
+	}
+
+	json := `{"phrase": "shooting up", "value": {"polarity": 1, "confidence": 0.9}}`
+	sentiments, err := LoadLexiconOf[sentiment](strings.NewReader(json), FormatJSON, nil)
+	assert.Nil(t, err)
+
+	member, s := sentiments.IsMember([]string{"shooting", "up"})
+	assert.True(t, member)
+	assert.Equal(t, sentiment{1, 0.9}, s)
+
+	labels, err := LoadLexiconOf(strings.NewReader("phrase,value\nr/g,color\n"), FormatCSV, func(s string) (string, error) {
+		return s, nil
+	})
+	assert.Nil(t, err)
+
+	member, label := labels.IsMember([]string{"r/g"})
+	assert.True(t, member)
+	assert.Equal(t, "color", label)
+
+	// with a Tokenizer
+	emoticons, err := LoadLexiconWith(strings.NewReader(":)\t1\n+1\t2\n"), FormatTSV, strconv.Atoi, WhitespaceTokenizer)
+	assert.Nil(t, err)
+
+	member, value := emoticons.IsMember([]string{":)"})
+	assert.True(t, member)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, len(emoticons.FindAllMembersText("great :) +1")))
+
+	// unknown format
+	_, err = LoadLexicon(strings.NewReader(""), LexiconFormat(42))
+	assert.EqualError(t, err, "trie: unknown lexicon format LexiconFormat(42)")
+	assert.Equal(t, "CSV", FormatCSV.String())
+}
@@ -75,7 +75,44 @@ func (n *PhraseTrieNode) Add(phrase []string, value int) {
 
 // Remove recursively removes a phrase from this Trie
 // Preserves other phrases if other nodes use the same prefixes
-func (n *PhraseTrieNode) Remove(phrase []string) {
+// and prunes any nodes that no longer lead to a phrase
+// Must be called on the root node, returns true if the phrase
+// was found and removed
+func (n *PhraseTrieNode) Remove(phrase []string) bool {
+	if n.key != "" || len(phrase) == 0 { // not root or nothing to remove
+		return false
+	}
+
+	return remove(&n.children, phrase)
+}
+
+// remove recursively removes a phrase from the sibling list starting at *link
+// unlinking the matched node from its siblings once it no longer has children
+func remove(link **PhraseTrieNode, phrase []string) bool {
+	for ; *link != nil; link = &(*link).next {
+		n := *link
+		if n.key != phrase[0] {
+			continue
+		}
+
+		if len(phrase) != 1 {
+			if n.IsLeaf() || !remove(&n.children, phrase[1:]) { // full phrase not member
+				return false
+			}
+
+			if !n.IsLeaf() { // still a prefix to other phrases
+				return true
+			}
+		} else if !n.IsLeaf() { // must be leaf, cant remove partial
+			return false
+		}
+
+		// unlink this node from its siblings
+		*link = n.next
+		return true
+	}
+
+	return false
 }
 
 // IsMember checks if the given phrase is a member of this Phrase Trie tree
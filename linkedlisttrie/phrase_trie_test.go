@@ -259,6 +259,100 @@ func TestAdd(t *testing.T) {
 }
 
 func TestRemove(t *testing.T) {
+	// make empty root trie first
+	trie := NewPhraseTrie(nil)
+
+	p1 := []string{"break"}
+	p2 := []string{"shooting"}
+	p3 := []string{"break", "out"}
+	p4 := []string{"break", "up"}
+	p5 := []string{"shooting", "up"}
+	p6 := []string{"break", "out", "nicely"}
+	p7 := []string{"r/g"}
+
+	// empty trie
+	assert.False(t, trie.Remove(p1))
+	assert.False(t, trie.Remove(nil))
+
+	// single phrase, trie is empty again
+	trie.Add(p1, 1)
+	assert.True(t, trie.Remove(p1))
+	assert.True(t, trie.IsLeaf())
+
+	member, _ := trie.IsMember(p1)
+	assert.False(t, member)
+
+	// head of sibling list
+	trie.Add(p1, 1)
+	trie.Add(p2, 2)
+	trie.Add(p7, 7)
+
+	assert.True(t, trie.Remove(p1))
+	assert.Equal(t, "shooting", trie.children.key)
+
+	member, value := trie.IsMember(p2)
+	assert.True(t, member)
+	assert.Equal(t, 2, value)
+
+	member, value = trie.IsMember(p7)
+	assert.True(t, member)
+	assert.Equal(t, 7, value)
+
+	// middle and tail of sibling list
+	trie.Add(p1, 1)
+	assert.True(t, trie.Remove(p7))
+	assert.Nil(t, trie.children.next.next)
+	assert.True(t, trie.Remove(p2))
+	assert.Equal(t, "break", trie.children.key)
+	assert.False(t, trie.children.HasNext())
+
+	// not members
+	assert.False(t, trie.Remove(p2))
+	assert.False(t, trie.Remove(p3))
+
+	// reset
+	trie = NewPhraseTrie(nil)
+	trie.Add(p3, 3)
+	trie.Add(p4, 4)
+	trie.Add(p5, 5)
+	trie.Add(p6, 6)
+
+	// partial phrase is not a member
+	assert.False(t, trie.Remove(p3))
+	assert.False(t, trie.Remove([]string{"break"}))
+
+	// remove 3 level phrase, collapses children chain down to break
+	assert.True(t, trie.Remove(p6))
+
+	member, _ = trie.IsMember(p6)
+	assert.False(t, member)
+
+	member, value = trie.IsMember(p4)
+	assert.True(t, member)
+	assert.Equal(t, 4, value)
+
+	// out had no other children, so it is gone too
+	assert.Equal(t, "up", trie.children.children.key)
+	assert.False(t, trie.children.children.HasNext())
+
+	// removing last child of break removes break from the root list
+	assert.True(t, trie.Remove(p4))
+	assert.Equal(t, "shooting", trie.children.key)
+	assert.False(t, trie.children.HasNext())
+
+	member, _ = trie.IsMember(p1)
+	assert.False(t, member)
+
+	member, value = trie.IsMember(p5)
+	assert.True(t, member)
+	assert.Equal(t, 5, value)
+
+	assert.True(t, trie.Remove(p5))
+	assert.True(t, trie.IsLeaf())
+
+	// non root
+	trie = testTrieFull()
+	assert.False(t, trie.children.Remove(p7))
 }
 
 func BenchmarkAdd(b *testing.B) {
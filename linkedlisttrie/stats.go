@@ -0,0 +1,71 @@
+package linkedlisttrie
+
+import (
+	"unsafe"
+)
+
+// Stats describes the shape and estimated memory use of a Trie, with the
+// same fields as the Stats of the vector based implementation
+// The depth of a node is its distance from the root, so the depth
+// of a member phrase is its number of words
+type Stats struct {
+	Nodes    int     // number of nodes, including the root
+	Phrases  int     // number of member phrases
+	MaxDepth int     // depth of the longest member phrase
+	AvgDepth float64 // average depth of the member phrases
+
+	// Fanout is the fanout distribution per level, Fanout[d][k] is the
+	// number of nodes at depth d with k children
+	Fanout []map[int]int
+
+	// Bytes is the estimated heap size of the Trie nodes and their keys,
+	// not counting memory referenced by the values
+	Bytes int64
+}
+
+// Stats walks this Trie and returns its Stats, so it can be compared
+// with the vector based implementation
+// Must be called on the root node
+func (n *PhraseTrieNode) Stats() Stats {
+	var (
+		s     Stats
+		total int
+	)
+
+	var visit func(node *PhraseTrieNode, depth int)
+	visit = func(node *PhraseTrieNode, depth int) {
+		children := 0
+		for child := node.children; child != nil; child = child.next {
+			children++
+		}
+
+		s.Nodes++
+		s.Bytes += int64(unsafe.Sizeof(*node)) + int64(len(node.key))
+
+		for len(s.Fanout) <= depth {
+			s.Fanout = append(s.Fanout, map[int]int{})
+		}
+		s.Fanout[depth][children]++
+
+		if depth != 0 && node.IsLeaf() { // only leaves are members
+			s.Phrases++
+			total += depth
+
+			if depth > s.MaxDepth {
+				s.MaxDepth = depth
+			}
+		}
+
+		for child := node.children; child != nil; child = child.next {
+			visit(child, depth+1)
+		}
+	}
+
+	visit(n, 0)
+
+	if s.Phrases != 0 {
+		s.AvgDepth = float64(total) / float64(s.Phrases)
+	}
+
+	return s
+}
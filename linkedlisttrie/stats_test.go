@@ -0,0 +1,30 @@
+package linkedlisttrie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	s := testTrieFull().Stats()
+
+	// only leaves are members, so "break", "break out" and "shooting" are not
+	assert.Equal(t, 8, s.Nodes)
+	assert.Equal(t, 4, s.Phrases)
+	assert.Equal(t, 3, s.MaxDepth)
+	assert.Equal(t, 2.0, s.AvgDepth)
+	assert.Equal(t, []map[int]int{
+		{3: 1},
+		{0: 1, 1: 1, 2: 1},
+		{0: 2, 1: 1},
+		{0: 1},
+	}, s.Fanout)
+	assert.True(t, s.Bytes > 8*32)
+
+	// empty
+	s = NewPhraseTrie(nil).Stats()
+	assert.Equal(t, 1, s.Nodes)
+	assert.Equal(t, 0, s.Phrases)
+	assert.Equal(t, []map[int]int{{0: 1}}, s.Fanout)
+}
@@ -0,0 +1,392 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+/* MEMORY MAPPED IMPLEMENTATION */
+
+// A MappedPhraseTrie is a read-only PhraseTrie with int values that is
+// queried in place from its on-disk format, with no deserialization step
+//
+// OpenMapped memory maps the file read-only, so every process that opens
+// the same file shares one copy of it in the page cache and opening even
+// a multi-GB lexicon is instant. A MappedPhraseTrie is safe for concurrent
+// use, Close waits for running lookups so it never unmaps memory under them
+//
+// The file holds the arrays of a FrozenPhraseTrie, all little endian and
+// 8 byte aligned:
+//
+//	header        32 bytes, see below
+//	keyOffsets    nodes+1 uint64, the key of node i is keys[keyOffsets[i]:keyOffsets[i+1]]
+//	values        nodes int64, the value of node i
+//	childOffsets  nodes+1 uint32, the children of node i are childOffsets[i]:childOffsets[i+1]
+//	terminals     (nodes+7)/8 bytes, bit i%8 of byte i/8 is set if node i ends a phrase
+//	keys          the node keys concatenated, children sorted by key
+//
+// and the header is
+//
+//	magic         4 bytes "PTRM"
+//	version       uint32
+//	nodes         uint32
+//	checksum      uint32 CRC-32 (IEEE) of everything after the header
+//	keysLen       uint64
+//	reserved      8 bytes
+type MappedPhraseTrie struct {
+	mu    sync.RWMutex // held for reading by lookups, for writing by Close
+	data  []byte
+	unmap func() error
+
+	nodes        int
+	keyOffsets   int
+	values       int
+	childOffsets int
+	terminals    int
+	keys         int
+}
+
+const (
+	mappedMagic      = "PTRM"
+	mappedVersion    = 1
+	mappedHeaderSize = 32
+)
+
+// ErrClosed is returned when using a MappedPhraseTrie after Close
+var ErrClosed = errors.New("trie: mapped trie is closed")
+
+// WriteMapped writes the current phrases of the Trie to w in the
+// MappedPhraseTrie file format
+// Membership follows the Trie's mode, see SetLeafOnly. A Normalizer cannot be
+// stored in the file, so sentences looked up in a MappedPhraseTrie of a Trie
+// with a Normalizer must be normalized by the caller
+//
+// Returns ErrTooLarge if the Trie cannot be frozen, see Freeze
+//
+// Never rewrite a file that is mapped by a running process in place,
+// write a new file and rename it over the old one instead
+func WriteMapped(w io.Writer, n *PhraseTrieNode) (int64, error) {
+	f, err := n.freeze()
+	if err != nil {
+		return 0, err
+	}
+
+	nodes := len(f.valueIndices)
+
+	// sections after the header
+	var body []byte
+	body = appendAligned(body, 8*(nodes+1), func(b []byte) {
+		for i, off := range f.keyOffsets {
+			binary.LittleEndian.PutUint64(b[8*i:], uint64(off))
+		}
+	})
+	body = appendAligned(body, 8*nodes, func(b []byte) {
+		for i, vi := range f.valueIndices {
+			if vi != -1 {
+				binary.LittleEndian.PutUint64(b[8*i:], uint64(int64(f.values[vi])))
+			}
+		}
+	})
+	body = appendAligned(body, 4*(nodes+1), func(b []byte) {
+		for i, off := range f.childOffsets {
+			binary.LittleEndian.PutUint32(b[4*i:], off)
+		}
+	})
+	body = appendAligned(body, (nodes+7)/8, func(b []byte) {
+		for i, vi := range f.valueIndices {
+			if vi != -1 {
+				b[i/8] |= 1 << (i % 8)
+			}
+		}
+	})
+	body = appendAligned(body, len(f.keys), func(b []byte) {
+		copy(b, f.keys)
+	})
+
+	header := make([]byte, mappedHeaderSize)
+	copy(header, mappedMagic)
+	binary.LittleEndian.PutUint32(header[4:], mappedVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(nodes))
+	binary.LittleEndian.PutUint32(header[12:], crc32.ChecksumIEEE(body))
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(f.keys)))
+
+	written, err := w.Write(header)
+	if err != nil {
+		return int64(written), err
+	}
+
+	bodyWritten, err := w.Write(body)
+
+	return int64(written + bodyWritten), err
+}
+
+// appendAligned appends a section of size bytes filled by fill,
+// padded with zeros to a multiple of 8 bytes
+func appendAligned(b []byte, size int, fill func([]byte)) []byte {
+	start := len(b)
+	b = append(b, make([]byte, align8(size))...)
+	fill(b[start : start+size])
+
+	return b
+}
+
+func align8(size int) int {
+	return (size + 7) &^ 7
+}
+
+// OpenMapped opens a file written by WriteMapped and memory maps it read-only
+// On platforms without mmap support the file is read into memory instead
+// The MappedPhraseTrie must be closed to release the mapping
+func OpenMapped(path string) (*MappedPhraseTrie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < mappedHeaderSize || info.Size() != int64(int(info.Size())) {
+		return nil, ErrInvalidFormat
+	}
+
+	data, unmap, err := mmapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewMappedPhraseTrie(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+
+	m.unmap = unmap
+
+	return m, nil
+}
+
+// NewMappedPhraseTrie queries data in the MappedPhraseTrie file format in place,
+// e.g. a file mapped by the caller or embedded in the binary
+// Only the header and section sizes are validated, see Verify
+func NewMappedPhraseTrie(data []byte) (*MappedPhraseTrie, error) {
+	if len(data) < mappedHeaderSize || string(data[:len(mappedMagic)]) != mappedMagic {
+		return nil, ErrInvalidFormat
+	}
+
+	if binary.LittleEndian.Uint32(data[4:]) != mappedVersion {
+		return nil, ErrUnsupportedVersion
+	}
+
+	nodes := uint64(binary.LittleEndian.Uint32(data[8:]))
+	keysLen := binary.LittleEndian.Uint64(data[16:])
+
+	if nodes == 0 || keysLen > uint64(len(data)) {
+		return nil, ErrInvalidFormat
+	}
+
+	m := &MappedPhraseTrie{data: data, nodes: int(nodes)}
+	m.keyOffsets = mappedHeaderSize
+	m.values = m.keyOffsets + align8(8*(m.nodes+1))
+	m.childOffsets = m.values + align8(8*m.nodes)
+	m.terminals = m.childOffsets + align8(4*(m.nodes+1))
+	m.keys = m.terminals + align8((m.nodes+7)/8)
+
+	if len(data) != m.keys+align8(int(keysLen)) {
+		return nil, ErrInvalidFormat
+	}
+
+	if m.keyOffset(m.nodes) != keysLen || m.childOffset(m.nodes) != uint32(m.nodes) {
+		return nil, ErrInvalidFormat
+	}
+
+	return m, nil
+}
+
+// Verify checks the checksum of the whole file and that every offset stays
+// inside its array, reading every page of it
+// Lookups on a corrupted file that fails Verify may panic
+func (m *MappedPhraseTrie) Verify() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.data == nil {
+		return ErrClosed
+	}
+
+	if crc32.ChecksumIEEE(m.data[mappedHeaderSize:]) != binary.LittleEndian.Uint32(m.data[12:]) {
+		return ErrChecksum
+	}
+
+	for i := 0; i < m.nodes; i++ {
+		if m.keyOffset(i) > m.keyOffset(i+1) || m.childOffset(i) > m.childOffset(i+1) {
+			return ErrInvalidFormat
+		}
+	}
+
+	return nil
+}
+
+// Close releases the memory mapping once running lookups finish
+// Lookups after Close find no phrases
+func (m *MappedPhraseTrie) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.data == nil {
+		return ErrClosed
+	}
+
+	m.data = nil
+
+	if m.unmap != nil {
+		return m.unmap()
+	}
+
+	return nil
+}
+
+// IsMember checks if the given phrase is a member of this Trie
+// and returns the phrase value if true
+func (m *MappedPhraseTrie) IsMember(phrase []string) (bool, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(phrase) == 0 || m.data == nil {
+		return false, 0
+	}
+
+	node := 0
+	for _, word := range phrase {
+		if node = m.child(node, word); node == -1 {
+			return false, 0
+		}
+	}
+
+	if !m.terminal(node) {
+		return false, 0
+	}
+
+	return true, m.value(node)
+}
+
+// FindMember finds the longest member phrase the given sequence begins with,
+// like PhraseTrie.FindMember
+func (m *MappedPhraseTrie) FindMember(sequence []string) (bool, []string, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.data == nil {
+		return false, []string{}, 0
+	}
+
+	var value int
+
+	length, end := m.longest(sequence)
+	if length != 0 {
+		value = m.value(end)
+	}
+
+	phrase := make([]string, length)
+	copy(phrase, sequence[:length])
+
+	return length != 0, phrase, value
+}
+
+// FindAllMembers finds the longest member phrase starting at each index of
+// the sentence, like PhraseTrie.FindAllMembers
+func (m *MappedPhraseTrie) FindAllMembers(sentence []string) PCtxList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.data == nil {
+		return nil
+	}
+
+	foundMembers := make(PCtxList, 0)
+
+	for i := 0; i < len(sentence); i++ {
+		if m.childOffset(0) == m.childOffset(1) { // no children to match
+			return nil
+		}
+
+		length, end := m.longest(sentence[i:])
+		if length != 0 { // valid phrase was found
+			phrase := make([]string, length)
+			copy(phrase, sentence[i:i+length])
+
+			foundMembers = append(foundMembers, NewPhraseContext(phrase, sentence, []int{i, i + length - 1}, m.value(end)))
+		}
+	}
+
+	return foundMembers
+}
+
+// longest returns the length and end node of the longest member phrase
+// the sequence begins with, 0 if there is none
+func (m *MappedPhraseTrie) longest(sequence []string) (int, int) {
+	var length, end int
+
+	node := 0
+	for i, word := range sequence {
+		if node = m.child(node, word); node == -1 { // dead end
+			break
+		}
+
+		if m.terminal(node) { // longest phrase so far
+			length = i + 1
+			end = node
+		}
+	}
+
+	return length, end
+}
+
+// child binary searches the children of node for the given key
+// Returns the child node number, or -1 if there is none
+func (m *MappedPhraseTrie) child(node int, key string) int {
+	lo, hi := int(m.childOffset(node)), int(m.childOffset(node+1))
+
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+
+		switch k := m.key(mid); {
+		case string(k) == key:
+			return mid
+		case string(k) < key:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return -1
+}
+
+func (m *MappedPhraseTrie) key(i int) []byte {
+	keys := m.data[m.keys:]
+	return keys[m.keyOffset(i):m.keyOffset(i+1)]
+}
+
+func (m *MappedPhraseTrie) keyOffset(i int) uint64 {
+	return binary.LittleEndian.Uint64(m.data[m.keyOffsets+8*i:])
+}
+
+func (m *MappedPhraseTrie) childOffset(i int) uint32 {
+	return binary.LittleEndian.Uint32(m.data[m.childOffsets+4*i:])
+}
+
+func (m *MappedPhraseTrie) value(i int) int {
+	return int(int64(binary.LittleEndian.Uint64(m.data[m.values+8*i:])))
+}
+
+func (m *MappedPhraseTrie) terminal(i int) bool {
+	return m.data[m.terminals+i/8]&(1<<(i%8)) != 0
+}
@@ -0,0 +1,238 @@
+package trie
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockMappedFile(t *testing.T, trie *PhraseTrieNode) string {
+	path := filepath.Join(t.TempDir(), "lexicon.ptrm")
+
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+
+	_, err = WriteMapped(file, trie)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	return path
+}
+
+func TestWriteMapped(t *testing.T) {
+	var buf bytes.Buffer
+
+	written, err := WriteMapped(&buf, mockTrieFull())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), written)
+	assert.Equal(t, 0, buf.Len()%8)
+	assert.Equal(t, "PTRM", buf.String()[:4])
+
+	m, err := NewMappedPhraseTrie(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, m.Verify())
+	assert.Equal(t, 13, m.nodes)
+
+	// root children sorted by key
+	assert.Equal(t, "break", string(m.key(1)))
+	assert.Equal(t, "shooting", string(m.key(5)))
+	assert.Equal(t, 5, m.child(0, "shooting"))
+	assert.Equal(t, -1, m.child(0, "$AAPL"))
+
+	// in memory data has nothing to unmap
+	assert.Nil(t, m.Close())
+	assert.Equal(t, ErrClosed, m.Close())
+	assert.Equal(t, ErrClosed, m.Verify())
+
+	// keys too long for the offsets
+	defer func(max uint64) { maxFrozenKeys = max }(maxFrozenKeys)
+	maxFrozenKeys = 8
+
+	buf.Reset()
+	written, err = WriteMapped(&buf, mockTrieFull())
+	assert.Equal(t, ErrTooLarge, err)
+	assert.Equal(t, int64(0), written)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestOpenMapped(t *testing.T) {
+	trie := mockTrieFull()
+	trie.Add([]string{"big", "drop"}, -42)
+
+	m, err := OpenMapped(mockMappedFile(t, trie))
+	assert.Nil(t, err)
+	defer m.Close()
+
+	assert.Nil(t, m.Verify())
+
+	phrases := []string{
+		"break", "shooting", "break out", "break up", "shooting up", "break out nicely",
+		"r/g", "breaking double bottom", "double bottom", "big drop",
+		"breaking", "big", "break down", "$AAPL",
+	}
+
+	for _, p := range phrases {
+		member, value := trie.IsMember(strings.Split(p, " "))
+		mMember, mValue := m.IsMember(strings.Split(p, " "))
+
+		assert.Equal(t, member, mMember, p)
+		assert.Equal(t, value, mValue, p)
+
+		valid, phrase, value := trie.FindMember(strings.Split(p+" today", " "))
+		mValid, mPhrase, mValue := m.FindMember(strings.Split(p+" today", " "))
+
+		assert.Equal(t, valid, mValid, p)
+		assert.Equal(t, phrase, mPhrase, p)
+		assert.Equal(t, value, mValue, p)
+	}
+
+	member, _ := m.IsMember(nil)
+	assert.False(t, member)
+
+	sentences := []string{
+		"$AAPL isn't doing anything today",
+		"$AAPL isn't gonna break today",
+		"its shooting up it might even break up i bet $100 $AAPL will break out nicely",
+		"its breaking double bottom $100 $AAPL will big drop",
+		"",
+	}
+
+	for _, s := range sentences {
+		sSplit := strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), m.FindAllMembers(sSplit), s)
+	}
+
+	// shared by several readers
+	other, err := OpenMapped(mockMappedFile(t, trie))
+	assert.Nil(t, err)
+	assert.Equal(t, m.FindAllMembers(strings.Split(sentences[2], " ")), other.FindAllMembers(strings.Split(sentences[2], " ")))
+	assert.Nil(t, other.Close())
+}
+
+func TestOpenMappedLarge(t *testing.T) {
+	large, message := mockLexiconLarge()
+
+	m, err := OpenMapped(mockMappedFile(t, large))
+	assert.Nil(t, err)
+	defer m.Close()
+
+	assert.Nil(t, m.Verify())
+	assert.Equal(t, large.FindAllMembers(message), m.FindAllMembers(message))
+
+	// empty
+	empty, err := OpenMapped(mockMappedFile(t, NewPhraseTrie(nil)))
+	assert.Nil(t, err)
+	assert.Nil(t, empty.FindAllMembers([]string{"break"}))
+	assert.Nil(t, empty.Close())
+}
+
+func TestMappedClose(t *testing.T) {
+	large, message := mockLexiconLarge()
+
+	m, err := OpenMapped(mockMappedFile(t, large))
+	assert.Nil(t, err)
+	expected := m.FindAllMembers(message)
+
+	// lookups racing with Close see the whole trie or nothing
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if found := m.FindAllMembers(message); found != nil {
+					assert.Equal(t, expected, found)
+				}
+				m.IsMember(message[:2])
+				m.FindMember(message)
+			}
+		}()
+	}
+
+	assert.Nil(t, m.Close())
+	wg.Wait()
+
+	member, _ := m.IsMember([]string{"break"})
+	assert.False(t, member)
+	found, phrase, _ := m.FindMember([]string{"break", "out"})
+	assert.False(t, found)
+	assert.Empty(t, phrase)
+	assert.Nil(t, m.FindAllMembers(message))
+}
+
+func TestOpenMappedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := WriteMapped(&buf, mockTrieFull())
+	assert.Nil(t, err)
+	data := buf.Bytes()
+
+	// missing file
+	_, err = OpenMapped(filepath.Join(t.TempDir(), "missing.ptrm"))
+	assert.True(t, os.IsNotExist(err))
+
+	// too short or not a trie
+	_, err = NewMappedPhraseTrie(data[:16])
+	assert.Equal(t, ErrInvalidFormat, err)
+
+	bad := append([]byte("XXXX"), data[4:]...)
+	_, err = NewMappedPhraseTrie(bad)
+	assert.Equal(t, ErrInvalidFormat, err)
+
+	// newer version
+	bad = append([]byte(nil), data...)
+	bad[4] = mappedVersion + 1
+	_, err = NewMappedPhraseTrie(bad)
+	assert.Equal(t, ErrUnsupportedVersion, err)
+
+	// truncated
+	_, err = NewMappedPhraseTrie(data[:len(data)-8])
+	assert.Equal(t, ErrInvalidFormat, err)
+
+	path := filepath.Join(t.TempDir(), "truncated.ptrm")
+	assert.Nil(t, os.WriteFile(path, data[:len(data)-8], 0644))
+	_, err = OpenMapped(path)
+	assert.Equal(t, ErrInvalidFormat, err)
+
+	// corrupted
+	bad = append([]byte(nil), data...)
+	bad[len(bad)-9] ^= 0xff
+	m, err := NewMappedPhraseTrie(bad)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrChecksum, m.Verify())
+}
+
+func BenchmarkOpenMapped(b *testing.B) {
+	large, _ := mockLexiconLarge()
+	path := filepath.Join(b.TempDir(), "lexicon.ptrm")
+
+	file, _ := os.Create(path)
+	_, _ = WriteMapped(file, large)
+	_ = file.Close()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		m, _ := OpenMapped(path)
+		_ = m.Close()
+	}
+}
+
+func BenchmarkMappedFindAllMembersLong(b *testing.B) {
+	large, long := mockLexiconLarge()
+
+	var buf bytes.Buffer
+	_, _ = WriteMapped(&buf, large)
+	m, _ := NewMappedPhraseTrie(buf.Bytes())
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = m.FindAllMembers(long)
+	}
+}
@@ -0,0 +1,161 @@
+package trie
+
+/* AHO-CORASICK WORD LEVEL AUTOMATON */
+
+// A Matcher is a compiled, read-only Aho-Corasick automaton built over the
+// word tokens of a PhraseTrie
+//
+// Each automaton state is a node of the PhraseTrie. A failure link points
+// from a state to the state of the longest proper suffix of its phrase that
+// is also a path in the Trie, so after a mismatch the search carries on from
+// the words it already matched instead of re-walking them. This finds all
+// member phrases of a sentence in a single linear pass
+//
+// A Matcher is a snapshot, changes to the PhraseTrie after Compile are not
+// reflected in it. It is safe for concurrent use
+type Matcher[V any] struct {
+	edges      map[matcherEdge]int32
+	states     []matcherState[V]
+	normalizer Normalizer
+}
+
+// matcherEdge is a goto transition from a state on a word
+type matcherEdge struct {
+	from int32
+	word string
+}
+
+// matcherState is a single automaton state
+// fail is the failure link and out the nearest terminal state along the
+// failure links, 0 (the root) if there is none
+type matcherState[V any] struct {
+	depth    int32
+	fail     int32
+	out      int32
+	terminal bool
+	value    V
+}
+
+// Compile builds a Matcher from the current phrases of this Trie
+// Membership and word normalization follow this Trie, see SetLeafOnly and
+// SetNormalizer. Wildcard phrase words are matched literally
+func (n *PhraseTrie[V]) Compile() *Matcher[V] {
+	m := &Matcher[V]{
+		edges:      make(map[matcherEdge]int32),
+		states:     []matcherState[V]{{}}, // root state
+		normalizer: n.normalizer(),
+	}
+
+	leafOnly := n.config().leafOnly
+
+	// breadth first so failure links always point to already linked states
+	nodes := []*PhraseTrie[V]{n}
+	for i := 0; i < len(nodes); i++ {
+		from := int32(i)
+
+		for _, child := range nodes[i].children {
+			id := int32(len(m.states))
+			state := matcherState[V]{depth: m.states[from].depth + 1}
+
+			if child.endsPhrase(leafOnly) {
+				state.terminal = true
+				state.value = child.value
+			}
+
+			// follow parent failure links to the longest suffix that continues with this word
+			if from != 0 {
+				f := m.states[from].fail
+				for {
+					if next, ok := m.edges[matcherEdge{f, child.key}]; ok {
+						state.fail = next
+						break
+					}
+
+					if f == 0 {
+						break
+					}
+
+					f = m.states[f].fail
+				}
+			}
+
+			if m.states[state.fail].terminal {
+				state.out = state.fail
+			} else {
+				state.out = m.states[state.fail].out
+			}
+
+			m.edges[matcherEdge{from, child.key}] = id
+			m.states = append(m.states, state)
+			nodes = append(nodes, child)
+		}
+	}
+
+	return m
+}
+
+// FindAllMembers finds all member phrases in the sentence in one pass
+//
+// Returns the same results as PhraseTrie.FindAllMembers on the compiled Trie:
+// the longest member phrase starting at each sentence index, ordered by index
+func (m *Matcher[V]) FindAllMembers(sentence []string) PCtxListOf[V] {
+	if len(m.states) == 1 { // no phrases to match
+		if len(sentence) == 0 {
+			return make(PCtxListOf[V], 0)
+		}
+
+		return nil
+	}
+
+	// longest phrase length and end state for each start index
+	words := normalizeSentence(m.normalizer, sentence)
+	lengths := make([]int32, len(words))
+	ends := make([]int32, len(words))
+
+	state := int32(0)
+	for j, word := range words {
+		for {
+			if next, ok := m.edges[matcherEdge{state, word}]; ok {
+				state = next
+				break
+			}
+
+			if state == 0 {
+				break
+			}
+
+			state = m.states[state].fail
+		}
+
+		// every phrase ending at j is a suffix of the current state
+		s := state
+		if !m.states[s].terminal {
+			s = m.states[s].out
+		}
+
+		for ; s != 0; s = m.states[s].out {
+			depth := m.states[s].depth
+			start := j - int(depth) + 1
+
+			if depth > lengths[start] {
+				lengths[start] = depth
+				ends[start] = s
+			}
+		}
+	}
+
+	foundMembers := make(PCtxListOf[V], 0)
+	for i, l := range lengths {
+		if l == 0 {
+			continue
+		}
+
+		phrase := make([]string, l)
+		copy(phrase, words[i:i+int(l)])
+
+		pc := NewPhraseContext(phrase, words, []int{i, i + int(l) - 1}, m.states[ends[i]].value)
+		foundMembers = append(foundMembers, restore(m.normalizer, pc, sentence))
+	}
+
+	return foundMembers
+}
@@ -0,0 +1,188 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockLongMessage repeats the mock trie phrases in a long message
+func mockLongMessage() []string {
+	s := "its shooting up it might even break up i bet $100 $AAPL will break out nicely "
+	s += "breaking double bottom on $TSLA r/g break out break out nicely shooting up "
+
+	return strings.Split(strings.TrimSpace(strings.Repeat(s, 20)), " ")
+}
+
+// mockLexiconLarge builds a lexicon of deep phrases over a small vocabulary,
+// so phrases share many prefixes and suffixes, and a long message from it
+func mockLexiconLarge() (*PhraseTrieNode, []string) {
+	r := rand.New(rand.NewSource(42))
+	vocab := make([]string, 50)
+	for i := range vocab {
+		vocab[i] = fmt.Sprintf("w%d", i)
+	}
+
+	trie := NewPhraseTrie(nil)
+	for i := 0; i < 5000; i++ {
+		phrase := make([]string, 2+r.Intn(7))
+		for j := range phrase {
+			phrase[j] = vocab[r.Intn(len(vocab)/(j+1))]
+		}
+
+		trie.Add(phrase, i)
+	}
+
+	message := make([]string, 2000)
+	for i := range message {
+		message[i] = vocab[r.Intn(len(vocab)/(i%4+1))]
+	}
+
+	return trie, message
+}
+
+func TestCompile(t *testing.T) {
+	trie := mockTrieFull()
+	m := trie.Compile()
+
+	// 1 root + 12 phrase nodes
+	assert.Equal(t, 13, len(m.states))
+	assert.Equal(t, 12, len(m.edges))
+
+	// failure links of breaking double bottom point to double bottom
+	breaking := m.edges[matcherEdge{0, "breaking"}]
+	double := m.edges[matcherEdge{breaking, "double"}]
+	bottom := m.edges[matcherEdge{double, "bottom"}]
+
+	assert.Equal(t, m.edges[matcherEdge{0, "double"}], m.states[double].fail)
+	assert.Equal(t, m.states[m.states[double].fail].depth, int32(1))
+	assert.True(t, m.states[m.states[bottom].fail].terminal)
+	assert.Equal(t, m.states[bottom].fail, m.states[bottom].out)
+	assert.Equal(t, int32(3), m.states[bottom].depth)
+}
+
+func TestMatcherFindAllMembers(t *testing.T) {
+	trie := mockTrieFull()
+	trie.Add([]string{"up", "i", "bet"}, 10)
+	trie.Add([]string{"out", "nicely", "breaking"}, 11)
+	trie.Add([]string{"nicely", "breaking", "double"}, 12)
+	m := trie.Compile()
+
+	sentences := []string{
+		"$AAPL isn't doing anything today",
+		"$AAPL isn't gonna break today",
+		"shooting up $AAPL is today!",
+		"$AAPL might break up today!",
+		"$AAPL will break out nicely",
+		"its shooting up it might even break up i bet $100 $AAPL will break out nicely",
+		"its breaking double bottom $100 $AAPL will break out",
+		"break out nicely breaking double bottom",
+		"break break break out out nicely",
+		"",
+	}
+
+	for _, s := range sentences {
+		sSplit := strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), m.FindAllMembers(sSplit), s)
+	}
+
+	long := mockLongMessage()
+	assert.Equal(t, trie.FindAllMembers(long), m.FindAllMembers(long))
+
+	large, message := mockLexiconLarge()
+	assert.Equal(t, large.FindAllMembers(message), large.Compile().FindAllMembers(message))
+
+	// overlapping phrases through failure links
+	phrases := m.FindAllMembers(strings.Split("will break out nicely breaking double bottom", " "))
+	assert.Equal(t, 5, len(phrases))
+	assert.Equal(t, "break out nicely", phrases[0].PhraseStr())
+	assert.Equal(t, []int{1, 3}, phrases[0].Indices)
+	assert.Equal(t, "out nicely breaking", phrases[1].PhraseStr())
+	assert.Equal(t, 11, phrases[1].Value)
+	assert.Equal(t, "nicely breaking double", phrases[2].PhraseStr())
+	assert.Equal(t, []int{3, 5}, phrases[2].Indices)
+	assert.Equal(t, "breaking double bottom", phrases[3].PhraseStr())
+	assert.Equal(t, "double bottom", phrases[4].PhraseStr())
+	assert.Equal(t, []int{5, 6}, phrases[4].Indices)
+}
+
+func TestMatcherSnapshot(t *testing.T) {
+	// empty trie
+	trie := NewPhraseTrie(nil)
+	m := trie.Compile()
+	assert.Nil(t, m.FindAllMembers([]string{"break"}))
+	assert.Equal(t, 0, len(m.FindAllMembers(nil)))
+
+	// later changes are not reflected
+	trie = mockTrieFull()
+	m = trie.Compile()
+	trie.Remove([]string{"shooting", "up"})
+	trie.Add([]string{"to", "the", "moon"}, 10)
+
+	sSplit := strings.Split("shooting up to the moon", " ")
+	phrases := m.FindAllMembers(sSplit)
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, "shooting up", phrases[0].PhraseStr())
+
+	assert.Equal(t, trie.FindAllMembers(sSplit), trie.Compile().FindAllMembers(sSplit))
+
+	// leaf-only mode
+	trie = mockTrieFull()
+	trie.SetLeafOnly(true)
+	m = trie.Compile()
+
+	for _, s := range []string{"will break out today", "break out nicely", "shooting at r/g"} {
+		sSplit = strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), m.FindAllMembers(sSplit), s)
+	}
+
+	// generic values
+	weights := NewPhraseTrieOf(map[string]float64{"break out": 0.75, "out nicely": 0.25})
+	found := weights.Compile().FindAllMembers(strings.Split("break out nicely", " "))
+	assert.Equal(t, 2, len(found))
+	assert.Equal(t, 0.75, found[0].Value)
+	assert.Equal(t, 0.25, found[1].Value)
+}
+
+func BenchmarkCompile(b *testing.B) {
+	trie := mockTrieFull()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.Compile()
+	}
+}
+
+func BenchmarkMatcherFindAllMembers(b *testing.B) {
+	m := mockTrieFull().Compile()
+	s := "its shooting up it might even break up i bet $100 $AAPL will break out nicely"
+	sSplit := strings.Split(s, " ")
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = m.FindAllMembers(sSplit)
+	}
+}
+
+func BenchmarkFindAllMembersLong(b *testing.B) {
+	trie, long := mockLexiconLarge()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.FindAllMembers(long)
+	}
+}
+
+func BenchmarkMatcherFindAllMembersLong(b *testing.B) {
+	trie, long := mockLexiconLarge()
+	m := trie.Compile()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = m.FindAllMembers(long)
+	}
+}
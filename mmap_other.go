@@ -0,0 +1,19 @@
+//go:build !unix
+
+package trie
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads size bytes of the file into memory on platforms
+// without mmap support
+func mmapFile(file *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package trie
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile memory maps size bytes of the file read-only and shared,
+// so all processes mapping the same file share its pages
+func mmapFile(file *os.File, size int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,216 @@
+package trie
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+/* WORD NORMALIZATION */
+
+// A Normalizer maps a word to the normal form it is stored and matched in,
+// so differently written words like "Break" and "break" match
+type Normalizer interface {
+	Normalize(word string) string
+}
+
+// NormalizerFunc adapts an ordinary function to a Normalizer
+type NormalizerFunc func(word string) string
+
+// Normalize calls f(word)
+func (f NormalizerFunc) Normalize(word string) string {
+	return f(word)
+}
+
+var (
+	// Lowercase maps every letter of a word to lower case
+	Lowercase Normalizer = NormalizerFunc(strings.ToLower)
+
+	// CaseFold maps every letter of a word to a single case with Unicode
+	// simple case folding, so e.g. "Σ", "σ" and "ς" all match
+	CaseFold Normalizer = NormalizerFunc(caseFold)
+
+	// TrimPunct trims punctuation from both ends of a word, e.g. "nicely!" to
+	// "nicely", keeping the leading # of hashtags and @ of mentions
+	TrimPunct Normalizer = NormalizerFunc(trimPunct)
+
+	// SquashElongation shortens runs of 3 or more of the same letter to 2,
+	// so "shoooooting" and "shoooting" both match "shooting"
+	SquashElongation Normalizer = NormalizerFunc(squashElongation)
+
+	// NFKC maps a word to Unicode Normalization Form KC, so composed and
+	// decomposed accents and compatibility forms like fullwidth "ＢＲＥＡＫ"
+	// or the ligature "ﬁ" match their plain forms
+	NFKC Normalizer = NormalizerFunc(norm.NFKC.String)
+)
+
+// Chain returns a Normalizer applying the given Normalizers in order
+func Chain(normalizers ...Normalizer) Normalizer {
+	return NormalizerFunc(func(word string) string {
+		for _, normalizer := range normalizers {
+			word = normalizer.Normalize(word)
+		}
+
+		return word
+	})
+}
+
+func caseFold(word string) string {
+	return strings.Map(func(r rune) rune {
+		return unicode.ToLower(unicode.ToUpper(r))
+	}, word)
+}
+
+func trimPunct(word string) string {
+	trimmed := strings.TrimRightFunc(word, unicode.IsPunct)
+
+	if r, size := utf8.DecodeRuneInString(trimmed); r == '#' || r == '@' {
+		return trimmed[:size] + strings.TrimLeftFunc(trimmed[size:], unicode.IsPunct)
+	}
+
+	return strings.TrimLeftFunc(trimmed, unicode.IsPunct)
+}
+
+func squashElongation(word string) string {
+	var (
+		b       strings.Builder
+		last    rune
+		repeats int
+	)
+
+	for _, r := range word {
+		if r == last {
+			repeats++
+		} else {
+			last, repeats = r, 1
+		}
+
+		if repeats <= 2 || !unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// SetNormalizer sets the Normalizer applied to every word of the phrases
+// added to and looked up in this Trie, from this node (normally the root)
+// Phrases already in the Trie are normalized again, phrases that become the
+// same keep the first value in Walk order. A nil Normalizer turns it off
+//
+// Lookups match the normalized words of a sentence, while the found phrase
+// contexts hold the original words, with the stored phrase in Pattern when
+// it differs. Words normalized to the empty string are left out of added
+// phrases, the wildcard words WildcardWord and WildcardWords are not normalized
+// Compile, Freeze and Intern carry the Normalizer over. It is not part of the
+// binary encoding, decoding into a Trie keeps the Normalizer it already has
+func (n *PhraseTrie[V]) SetNormalizer(normalizer Normalizer) {
+	n.setConfig().normalizer = normalizer
+
+	if normalizer == nil || n.IsLeaf() {
+		return
+	}
+
+	var entries []PhraseEntry[V]
+	n.walk(make([]string, 0, 8), false, func(phrase []string, value V) bool {
+		entries = append(entries, PhraseEntry[V]{phrase, value})
+		return true
+	})
+
+	n.children = []*PhraseTrie[V]{}
+	n.setChildIndex(nil)
+	n.setConfig().wildcards = false
+
+	for _, e := range entries {
+		n.Add(e.Phrase, e.Value)
+	}
+}
+
+// normalizePhrase returns the normalized words of a phrase to add or look up
+// with the Normalizer of this Trie, see normalizePhrase
+func (n *PhraseTrie[V]) normalizePhrase(phrase []string) []string {
+	return normalizePhrase(n.normalizer(), phrase)
+}
+
+// normalizeSentence returns the normalized words of a sentence to match
+// with the Normalizer of this Trie, see normalizeSentence
+func (n *PhraseTrie[V]) normalizeSentence(sentence []string) []string {
+	return normalizeSentence(n.normalizer(), sentence)
+}
+
+// restore points a context found with the Normalizer of this Trie back at
+// the original sentence, see restore
+func (n *PhraseTrie[V]) restore(pc *PhraseContextOf[V], sentence []string) *PhraseContextOf[V] {
+	return restore(n.normalizer(), pc, sentence)
+}
+
+// normalizePhrase returns the normalized words of a phrase to add or look up,
+// leaving out empty words, or the phrase itself if there is no Normalizer
+// Wildcard words are kept as they are
+func normalizePhrase(normalizer Normalizer, phrase []string) []string {
+	if normalizer == nil {
+		return phrase
+	}
+
+	words := make([]string, 0, len(phrase))
+	for _, word := range phrase {
+		if !isWildcard(word) {
+			word = normalizer.Normalize(word)
+		}
+
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// normalizeSentence returns the normalized words of a sentence to match,
+// or the sentence itself if there is no Normalizer
+func normalizeSentence(normalizer Normalizer, sentence []string) []string {
+	if normalizer == nil {
+		return sentence
+	}
+
+	words := make([]string, len(sentence))
+	for i, word := range sentence {
+		words[i] = normalizer.Normalize(word)
+	}
+
+	return words
+}
+
+// restore points a context found in the normalized words of a sentence
+// back at the original sentence, keeping the stored phrase in Pattern
+// if it differs from the original words
+func restore[V any](normalizer Normalizer, pc *PhraseContextOf[V], sentence []string) *PhraseContextOf[V] {
+	if normalizer == nil {
+		return pc
+	}
+
+	if pc.Pattern == nil { // the normalized words are the stored phrase
+		pc.Pattern = pc.Phrase
+	}
+
+	phrase := make([]string, len(pc.Phrase))
+	for i := range phrase {
+		if pc.Positions != nil {
+			phrase[i] = sentence[pc.Positions[i]]
+		} else {
+			phrase[i] = sentence[pc.Indices[0]+i]
+		}
+	}
+
+	pc.Phrase = phrase
+	pc.Sentence = sentence
+
+	if slices.Equal(pc.Pattern, pc.Phrase) {
+		pc.Pattern = nil
+	}
+
+	return pc
+}
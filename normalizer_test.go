@@ -0,0 +1,236 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizers(t *testing.T) {
+	assert.Equal(t, "break", Lowercase.Normalize("BReak"))
+	assert.Equal(t, "$aapl", Lowercase.Normalize("$AAPL"))
+
+	assert.Equal(t, "σσσ", CaseFold.Normalize("Σσς"))
+	assert.Equal(t, "straße", CaseFold.Normalize("STRAßE"))
+
+	assert.Equal(t, "nicely", TrimPunct.Normalize("nicely!"))
+	assert.Equal(t, "nicely", TrimPunct.Normalize("\"nicely...\""))
+	assert.Equal(t, "#bullish", TrimPunct.Normalize("#bullish!!"))
+	assert.Equal(t, "@trader", TrimPunct.Normalize("@trader:"))
+	assert.Equal(t, "#bullish", TrimPunct.Normalize("#'bullish'"))
+	assert.Equal(t, "$AAPL", TrimPunct.Normalize("$AAPL,"))
+	assert.Equal(t, "r/g", TrimPunct.Normalize("(r/g)"))
+	assert.Equal(t, "", TrimPunct.Normalize("!!!"))
+
+	assert.Equal(t, "shooting", SquashElongation.Normalize("shoooooting"))
+	assert.Equal(t, "shooting", SquashElongation.Normalize("shooting"))
+	assert.Equal(t, "noo", SquashElongation.Normalize("nooooo"))
+	assert.Equal(t, "$1000", SquashElongation.Normalize("$1000"))
+
+	assert.Equal(t, "BREAK", NFKC.Normalize("ＢＲＥＡＫ"))
+	assert.Equal(t, "café", NFKC.Normalize("cafe\u0301"))
+	assert.Equal(t, "fine", NFKC.Normalize("ﬁne"))
+
+	chain := Chain(TrimPunct, Lowercase, SquashElongation)
+	assert.Equal(t, "shooting", chain.Normalize("SHOOOOTING!!"))
+	assert.Equal(t, "word", Chain().Normalize("word"))
+}
+
+func TestSetNormalizer(t *testing.T) {
+	trie := NewPhraseTrie(map[string]int{
+		"Break Out":        3,
+		"break out nicely": 6,
+		"Shooting UP":      5,
+		"#Bullish":         8,
+	})
+	trie.SetNormalizer(Chain(TrimPunct, Lowercase, SquashElongation))
+
+	// existing phrases are normalized
+	member, value := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, _ = trie.IsMember([]string{"Break", "Out"})
+	assert.True(t, member)
+
+	member, _ = trie.IsMember([]string{"BREAK", "OUT!"})
+	assert.True(t, member)
+
+	// applied on add
+	trie.Add([]string{"BIG", "DROP!"}, -5)
+	member, value = trie.IsMember([]string{"big", "drop"})
+	assert.True(t, member)
+	assert.Equal(t, -5, value)
+
+	trie.Add([]string{"!!!"}, 1)
+	assert.Equal(t, 5, len(trie.WithPrefix(nil, 0, nil)))
+
+	// applied on lookup, the sentence keeps the original words
+	sentence := strings.Split("its SHOOOOTING up! i bet $AAPL will Break Out nicely... #BULLISH", " ")
+	phrases := trie.FindAllMembers(sentence)
+	assert.Equal(t, 3, len(phrases))
+
+	assert.Equal(t, []string{"SHOOOOTING", "up!"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"shooting", "up"}, phrases[0].Pattern)
+	assert.Equal(t, []int{1, 2}, phrases[0].Indices)
+	assert.Equal(t, 5, phrases[0].Value)
+	assert.Equal(t, sentence, phrases[0].Sentence)
+
+	assert.Equal(t, []string{"Break", "Out", "nicely..."}, phrases[1].Phrase)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrases[1].Pattern)
+	assert.Equal(t, 6, phrases[1].Value)
+
+	assert.Equal(t, []string{"#BULLISH"}, phrases[2].Phrase)
+	assert.Equal(t, 8, phrases[2].Value)
+
+	valid, phrase, value := trie.FindMember([]string{"Big", "Drop", "today"})
+	assert.True(t, valid)
+	assert.Equal(t, []string{"Big", "Drop"}, phrase)
+	assert.Equal(t, -5, value)
+
+	phrases = trie.FindMembersAt([]string{"BREAK", "OUT", "NICELY"})
+	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, []string{"BREAK", "OUT"}, phrases[0].Phrase)
+
+	// unchanged words have no pattern
+	phrases = trie.FindAllMembers([]string{"break", "out"})
+	assert.Nil(t, phrases[0].Pattern)
+
+	pc := trie.FindMemberSkip([]string{"Break", "right", "OUT"}, 1)
+	assert.Equal(t, []string{"Break", "OUT"}, pc.Phrase)
+	assert.Equal(t, []string{"break", "out"}, pc.Pattern)
+	assert.Equal(t, []int{0, 2}, pc.Positions)
+
+	pc = trie.FindMemberFuzzy([]string{"Breakk", "OUT"}, 1, 0)
+	assert.Equal(t, []string{"Breakk", "OUT"}, pc.Phrase)
+	assert.Equal(t, []string{"break", "out"}, pc.Pattern)
+	assert.Equal(t, 1, pc.Cost)
+
+	assert.Equal(t, 2, len(trie.WithPrefix([]string{"BREAK"}, 0, nil)))
+
+	assert.True(t, trie.Remove([]string{"Big", "Drop"}))
+	member, _ = trie.IsMember([]string{"big", "drop"})
+	assert.False(t, member)
+
+	// turned off
+	trie.SetNormalizer(nil)
+	member, _ = trie.IsMember([]string{"Break", "Out"})
+	assert.False(t, member)
+	member, _ = trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+}
+
+func TestSetNormalizerCollisions(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+	trie.Add([]string{"break", "out"}, 3)
+	trie.Add([]string{"Break", "Out"}, 30)
+
+	trie.SetNormalizer(Lowercase)
+
+	// Walk order is "Break Out" before "break out"
+	member, value := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 30, value)
+	assert.Equal(t, 1, len(trie.WithPrefix(nil, 0, nil)))
+}
+
+func TestSetNormalizerWildcards(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+	trie.Add([]string{"Break", "*", "Resistance"}, 4)
+	trie.SetNormalizer(Chain(TrimPunct, Lowercase, SquashElongation))
+	trie.Add([]string{"SHORT", "**", "today!"}, -6)
+
+	// wildcards survive normalizing, also when the phrases are rebuilt
+	member, value := trie.IsMember([]string{"break", "*", "resistance"})
+	assert.True(t, member)
+	assert.Equal(t, 4, value)
+
+	member, value = trie.IsMember([]string{"short", "**", "today"})
+	assert.True(t, member)
+	assert.Equal(t, -6, value)
+
+	member, _ = trie.IsMember([]string{"break", "resistance"})
+	assert.False(t, member)
+
+	phrases := trie.FindAllMembers(strings.Split("will BREAK the resistance and short it all today", " "))
+	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, []string{"BREAK", "the", "resistance"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"break", "*", "resistance"}, phrases[0].Pattern)
+	assert.Equal(t, []string{"short", "**", "today"}, phrases[1].Pattern)
+	assert.Equal(t, []int{5, 8}, phrases[1].Indices)
+}
+
+func TestNormalizerCompiled(t *testing.T) {
+	trie := NewPhraseTrie(map[string]int{
+		"break out":        3,
+		"break out nicely": 6,
+		"shooting up":      5,
+	})
+	trie.SetNormalizer(Chain(TrimPunct, Lowercase))
+
+	sentence := strings.Split("its SHOOTING up and will Break Out nicely!", " ")
+	expected := trie.FindAllMembers(sentence)
+	assert.Equal(t, 2, len(expected))
+	assert.Equal(t, []string{"SHOOTING", "up"}, expected[0].Phrase)
+	assert.Equal(t, []string{"shooting", "up"}, expected[0].Pattern)
+
+	// compiled forms apply the Normalizer too
+	assert.Equal(t, expected, trie.Compile().FindAllMembers(sentence))
+
+	frozen := trie.Freeze()
+	assert.Equal(t, expected, frozen.FindAllMembers(sentence))
+
+	member, value := frozen.IsMember([]string{"Break", "OUT!"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	found, phrase, value := frozen.FindMember([]string{"Break", "Out", "today"})
+	assert.True(t, found)
+	assert.Equal(t, []string{"Break", "Out"}, phrase)
+	assert.Equal(t, 3, value)
+
+	interned := trie.Intern(nil)
+	assert.Equal(t, expected, interned.FindAllMembers(interned.InternSentence(sentence)))
+
+	interned.Add([]string{"BIG", "DROP"}, -5)
+	member, value = interned.IsMember(interned.InternSentence([]string{"big", "drop!"}).IDs)
+	assert.True(t, member)
+	assert.Equal(t, -5, value)
+}
+
+func TestNormalizerUnmarshal(t *testing.T) {
+	trie := NewPhraseTrie(map[string]int{"Break Out": 3})
+	trie.SetNormalizer(Lowercase)
+
+	data, err := trie.MarshalBinary()
+	assert.Nil(t, err)
+
+	// the receiver keeps its Normalizer
+	decoded := NewPhraseTrie(nil)
+	decoded.SetNormalizer(Lowercase)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+
+	member, value := decoded.IsMember([]string{"BREAK", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	// and so does ReadFrom
+	_, err = decoded.ReadFrom(strings.NewReader(string(data)))
+	assert.Nil(t, err)
+
+	member, _ = decoded.IsMember([]string{"BREAK", "out"})
+	assert.True(t, member)
+}
+
+func BenchmarkNormalizedFindAllMembers(b *testing.B) {
+	trie := mockTrieFull()
+	trie.SetNormalizer(Chain(TrimPunct, Lowercase, SquashElongation))
+	sSplit := strings.Split("its SHOOOOTING up! it might even break up i bet $100 $AAPL will Break Out nicely...", " ")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.FindAllMembers(sSplit)
+	}
+}
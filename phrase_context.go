@@ -5,19 +5,36 @@ import (
 	"strings"
 )
 
-// PhraseContext contains the found phrase, the sentence in which the phrase was found,
-// the word indices of found phrase in the sentence, and the sentiment value of the phrase
-type PhraseContext struct {
-	Phrase   []string
-	Indices  []int
-	Value    int
-	Sentence []string
+// PhraseContextOf contains the found phrase, the sentence in which the phrase was found,
+// the word indices of found phrase in the sentence, and the value of the phrase
+// If the phrase matched a stored phrase that differs from it, e.g. one with
+// wildcards, Pattern is the stored phrase and Phrase holds the words of the
+// sentence it matched
+// Gap tolerant and approximate matches, see FindAllMembersSkip and
+// FindAllMembersFuzzy, also record the exact positions of the matched words
+// in Positions, so skipped words are the ones between Indices that are not
+// in Positions. Approximate matches record their edit Cost
+// Phrases found in raw text, see FindAllMembersText, also record their
+// [start, end) byte and rune offsets in the text in ByteOffsets and RuneOffsets
+type PhraseContextOf[V any] struct {
+	Phrase      []string
+	Indices     []int
+	Value       V
+	Sentence    []string
+	Pattern     []string
+	Positions   []int
+	Cost        int
+	ByteOffsets []int
+	RuneOffsets []int
 }
 
+// PhraseContext is a PhraseContextOf with an int sentiment value
+type PhraseContext = PhraseContextOf[int]
+
 // NewPhraseContext constructs and initializes a new PhraseContext
 // NOTE: Always use this constructor when creating a new PhraseContext
-func NewPhraseContext(phrase []string, sentence []string, indices []int, value int) *PhraseContext {
-	pc := PhraseContext{
+func NewPhraseContext[V any](phrase []string, sentence []string, indices []int, value V) *PhraseContextOf[V] {
+	pc := PhraseContextOf[V]{
 		Phrase:   phrase,
 		Indices:  indices,
 		Value:    value,
@@ -28,29 +45,32 @@ func NewPhraseContext(phrase []string, sentence []string, indices []int, value i
 }
 
 // SentenceStr returns this PhraseContext's sentence as a string
-func (p *PhraseContext) SentenceStr() string {
+func (p *PhraseContextOf[V]) SentenceStr() string {
 	return strings.Join(p.Sentence, " ")
 }
 
 // PhraseStr returns this PhraseContext's phrase as a string
-func (p *PhraseContext) PhraseStr() string {
+func (p *PhraseContextOf[V]) PhraseStr() string {
 	return strings.Join(p.Phrase, " ")
 }
 
-// PCtxList is a list of PhraseContext pointers
-// Implements sort.Interface for []*PhraseContext based on
+// PCtxListOf is a list of PhraseContextOf pointers
+// Implements sort.Interface for []*PhraseContextOf based on
 // lower bound indices first then upper bound
-type PCtxList []*PhraseContext
+type PCtxListOf[V any] []*PhraseContextOf[V]
+
+// PCtxList is a PCtxListOf with int sentiment values
+type PCtxList = PCtxListOf[int]
 
-func (pcl PCtxList) Len() int {
+func (pcl PCtxListOf[V]) Len() int {
 	return len(pcl)
 }
 
-func (pcl PCtxList) Swap(i, j int) {
+func (pcl PCtxListOf[V]) Swap(i, j int) {
 	pcl[i], pcl[j] = pcl[j], pcl[i]
 }
 
-func (pcl PCtxList) Less(i, j int) bool {
+func (pcl PCtxListOf[V]) Less(i, j int) bool {
 	iIndices := pcl[i].Indices
 	jIndices := pcl[j].Indices
 
@@ -74,9 +94,9 @@ func (pcl PCtxList) Less(i, j int) bool {
 // 	SuperOnly will remove 'double floor' from, as it is contained
 // in the word superset of another found phrase
 // Note: This will NOT remove pre super subphrases. that is complicated and not desirable
-func (pcl PCtxList) SuperOnly() PCtxList {
+func (pcl PCtxListOf[V]) SuperOnly() PCtxListOf[V] {
 	if len(pcl) == 0 {
-		return PCtxList{}
+		return PCtxListOf[V]{}
 	}
 
 	// sort first by indices
@@ -112,7 +132,7 @@ Outer:
 	}
 
 	// return only supers
-	supers := make(PCtxList, 0)
+	supers := make(PCtxListOf[V], 0)
 	last := -1
 	for i := 0; i < len(lookups); i++ {
 		if lookups[i] != -1 && lookups[i] != last {
@@ -101,10 +101,13 @@ func TestSuperOnly(t *testing.T) {
 	s = "its breaking double bottom $100 $AAPL will break out"
 	sSplit = strings.Split(s, " ")
 	supers = trie.FindAllMembers(sSplit).SuperOnly()
-	assert.Equal(t, 1, len(supers))
+	assert.Equal(t, 2, len(supers))
 	assert.Equal(t, []int{1, 3}, supers[0].Indices)
 	assert.Equal(t, "breaking double bottom", supers[0].PhraseStr())
 	assert.Equal(t, 8, supers[0].Value)
+	assert.Equal(t, []int{7, 8}, supers[1].Indices)
+	assert.Equal(t, "break out", supers[1].PhraseStr())
+	assert.Equal(t, 3, supers[1].Value)
 }
 
 func BenchmarkSuperOnly(b *testing.B) {
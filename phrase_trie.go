@@ -1,7 +1,8 @@
 package trie
 
 import (
-	"strings"
+	"iter"
+	"sort"
 )
 
 /* ARRAY BASED VECTOR IMPLEMENTAITON */
@@ -12,139 +13,413 @@ import (
 // A PhraseTrie is an implementation of a Trie tree but for single/multi word phrases
 // where a part of a phrase is a full word or expression
 
-// A PhraseTrieNode is a Trie element that stores its key/value pair
-// and a list of children nodes
-type PhraseTrieNode struct {
+// A PhraseTrie is a Trie element that stores its key/value pair,
+// whether it terminates a member phrase, and a list of children nodes
+// The value type V can be any type, e.g. an int sentiment score, a float
+// weight or a struct with polarity and confidence
+// Nodes with more than childIndexThreshold children also index their
+// children by key so lookups stay constant time on high fanout nodes
+//
+// By default any node marked terminal ends a member phrase, so a phrase
+// and its longer extensions (e.g. "break out" and "break out nicely")
+// can all be members. SetLeafOnly restores the legacy behavior where
+// only phrases ending in a leaf are valid members
+//
+// The phrase words WildcardWord and WildcardWords are wildcards that
+// FindMember, FindMembersAt and FindAllMembers match any word against
+type PhraseTrie[V any] struct {
 	key      string
-	value    int
-	children []*PhraseTrieNode
+	value    V
+	terminal bool
+	children []*PhraseTrie[V]
+	ext      *nodeExt[V] // nil on most nodes
+}
+
+// nodeExt holds the rarely used fields of a node, so they take no space
+// on the many nodes of a large Trie that need neither
+type nodeExt[V any] struct {
+	index map[string]*PhraseTrie[V] // children by key, nil on low fanout nodes
+	root  *rootConfig               // settings of a root, nil on other nodes
+}
+
+// rootConfig holds the settings of a root, used by lookups started from it
+type rootConfig struct {
+	leafOnly    bool
+	wildcards   bool  // set once a wildcard is added
+	wildcardCap int32 // 0 for DefaultWildcardCap
+	normalizer  Normalizer
+	tokenizer   Tokenizer
+}
+
+// noConfig is the config of a root with default settings
+var noConfig rootConfig
+
+// config returns the settings of this root for reading
+func (n *PhraseTrie[V]) config() *rootConfig {
+	if n.ext == nil || n.ext.root == nil {
+		return &noConfig
+	}
+
+	return n.ext.root
+}
+
+// setConfig returns the settings of this root for changing, creating them if needed
+func (n *PhraseTrie[V]) setConfig() *rootConfig {
+	if n.ext == nil {
+		n.ext = &nodeExt[V]{}
+	}
+
+	if n.ext.root == nil {
+		n.ext.root = &rootConfig{}
+	}
+
+	return n.ext.root
+}
+
+// copyConfig gives this root the settings of another root, normalizing
+// its phrases again if the other root has a Normalizer
+func (n *PhraseTrie[V]) copyConfig(from *PhraseTrie[V]) {
+	src := from.config()
+	if src == &noConfig {
+		return
+	}
+
+	config := n.setConfig()
+	config.leafOnly, config.wildcardCap, config.tokenizer = src.leafOnly, src.wildcardCap, src.tokenizer
+	n.SetNormalizer(src.normalizer)
+}
+
+// normalizer returns the Normalizer of this root, or nil if there is none
+func (n *PhraseTrie[V]) normalizer() Normalizer {
+	return n.config().normalizer
 }
 
+// tokenizer returns the Tokenizer of this root, or the default one if there is none
+func (n *PhraseTrie[V]) tokenizer() Tokenizer {
+	if tokenizer := n.config().tokenizer; tokenizer != nil {
+		return tokenizer
+	}
+
+	return defaultTokenizer
+}
+
+// childIndexThreshold is the number of children above which a node
+// indexes its children in a map instead of scanning the children slice
+const childIndexThreshold = 16
+
+// A PhraseTrieNode is a PhraseTrie with int phrase values
+type PhraseTrieNode = PhraseTrie[int]
+
 // NewPhraseTrie creates a new Trie tree by initializing and returning a root Node
 // as the base of the Trie.
 // If phrases key/value map is supplied, adds all the given phrases to the Trie
-// to create the full phrase tree
+// to create the full phrase tree, splitting the keys into words with a SocialTokenizer
+// Keys are no longer split on spaces only: punctuation is separated from words,
+// so keys like ":)", "10%" or "+1" become several words and are not found in
+// sentences split with strings.Split. Use NewPhraseTrieWith and
+// WhitespaceTokenizer to split keys on spaces, or match text with FindAllMembersText
 func NewPhraseTrie(phrases map[string]int) *PhraseTrieNode {
-	root := &PhraseTrieNode{children: []*PhraseTrieNode{}} // init children to 0 len slice
+	return NewPhraseTrieOf(phrases)
+}
+
+// NewPhraseTrieOf is the generic form of NewPhraseTrie for any phrase value type
+func NewPhraseTrieOf[V any](phrases map[string]V) *PhraseTrie[V] {
+	return NewPhraseTrieWith(phrases, nil)
+}
+
+// NewPhraseTrieWith is NewPhraseTrieOf splitting the keys into words with the
+// given Tokenizer, which FindAllMembersText then splits text with too, see
+// SetTokenizer. A nil Tokenizer is the default SocialTokenizer
+func NewPhraseTrieWith[V any](phrases map[string]V, tokenizer Tokenizer) *PhraseTrie[V] {
+	root := &PhraseTrie[V]{children: []*PhraseTrie[V]{}} // init children to 0 len slice
+	if tokenizer != nil {
+		root.SetTokenizer(tokenizer)
+	}
 
 	for k, v := range phrases {
-		root.Add(strings.Split(k, " "), v)
+		root.Add(root.tokenizer().Tokenize(k), v)
 	}
 
 	return root
 }
 
-// Add recursively adds a phrase key/value to this Trie
-// Note: if adding a multi word phrase with a prefix that
-// already exists in the Trie, that prefix will no longer
-// be a valid phrase member of the Trie. Only full phrases
-// that end in a leaf are valid members
-func (n *PhraseTrieNode) Add(phrase []string, value int) {
-	if n.IsLeaf() {
-		n.children = []*PhraseTrieNode{&PhraseTrieNode{key: phrase[0]}}
-		if len(phrase) != 1 {
-			n.children[0].Add(phrase[1:], value)
-		} else { // leaf, set value
-			n.children[0].value = value
-		}
-	} else { // has children
-		for i, child := range n.children {
-			if phrase[0] == child.key {
-				if len(phrase) != 1 {
-					n.children[i].Add(phrase[1:], value)
-				} // else already exists, return from function
-
-				return
+// SetLeafOnly toggles the legacy leaf-only membership mode for lookups
+// started from this node (normally the root).
+// In leaf-only mode a phrase is only a member if it ends in a leaf, so
+// adding a multi word phrase with a prefix that already exists in the
+// Trie hides that prefix from IsMember, FindMember and FindAllMembers
+func (n *PhraseTrie[V]) SetLeafOnly(leafOnly bool) {
+	n.setConfig().leafOnly = leafOnly
+}
+
+// Add adds a phrase key/value to this Trie
+// and marks the final node of the phrase as terminal
+// Note: if the phrase already exists in the Trie its value is kept,
+// use Set or Update to change the value of an existing phrase
+func (n *PhraseTrie[V]) Add(phrase []string, value V) {
+	phrase = n.normalizePhrase(phrase)
+	if len(phrase) == 0 {
+		return
+	}
+
+	end := n.insert(phrase)
+	if !end.terminal { // new phrase, set value
+		end.value = value
+		end.terminal = true
+	} // else already exists, keep value
+}
+
+// Set adds a phrase key/value to this Trie, overwriting the value
+// if the phrase already exists
+// Returns the previous value and true if the phrase already existed
+func (n *PhraseTrie[V]) Set(phrase []string, value V) (V, bool) {
+	var prev V
+
+	phrase = n.normalizePhrase(phrase)
+	if len(phrase) == 0 {
+		return prev, false
+	}
+
+	end := n.insert(phrase)
+	existed := end.terminal
+	if existed {
+		prev = end.value
+	}
+
+	end.value = value
+	end.terminal = true
+
+	return prev, existed
+}
+
+// Update sets the value of a phrase in this Trie to the result of fn,
+// which is called with the current value and true if the phrase exists,
+// or the zero value and false if it does not. Adds the phrase if needed
+// Returns the new value
+//
+// Useful for accumulating or re-weighting values when merging lexicons, e.g.
+//
+//	t.Update(phrase, func(old int, ok bool) int { return old + v })
+func (n *PhraseTrie[V]) Update(phrase []string, fn func(old V, ok bool) V) V {
+	var old V
+
+	phrase = n.normalizePhrase(phrase)
+	if len(phrase) == 0 {
+		return old
+	}
+
+	end := n.insert(phrase)
+	if end.terminal {
+		old = end.value
+	}
+
+	end.value = fn(old, end.terminal)
+	end.terminal = true
+
+	return end.value
+}
+
+// insert walks a phrase down this Trie, adding any missing nodes,
+// and returns the node the phrase ends on
+func (n *PhraseTrie[V]) insert(phrase []string) *PhraseTrie[V] {
+	node := n
+
+	for _, word := range phrase {
+		child := node.child(word)
+		if child == nil { // add new node
+			child = &PhraseTrie[V]{key: word}
+			node.addChild(child)
+
+			if isWildcard(word) {
+				n.setConfig().wildcards = true
 			}
 		}
 
-		// add new node
-		n.children = append(n.children, &PhraseTrieNode{key: phrase[0]})
-		if len(phrase) != 1 {
-			n.children[len(n.children)-1].Add(phrase[1:], value)
-		} else { // leaf, set value
-			n.children[len(n.children)-1].value = value
-		}
+		node = child
 	}
+
+	return node
 }
 
 // Remove recursively removes a phrase from this Trie
 // Preserves other phrases if other nodes use the same prefixes
-// TODO: implement
-func (n *PhraseTrieNode) Remove(phrase []string) {
+// and prunes any nodes that no longer lead to a phrase
+// Returns true if the phrase was found and removed
+func (n *PhraseTrie[V]) Remove(phrase []string) bool {
+	phrase = n.normalizePhrase(phrase)
+	if len(phrase) == 0 {
+		return false
+	}
+
+	child := n.child(phrase[0])
+	if child == nil {
+		return false
+	}
+
+	if len(phrase) != 1 {
+		if !child.Remove(phrase[1:]) {
+			return false
+		}
+	} else if child.terminal { // end of phrase, clear it
+		var zero V
+		child.terminal = false
+		child.value = zero
+	} else { // only a prefix, nothing to remove
+		return false
+	}
+
+	// prune child if it no longer leads to any phrase
+	if !child.terminal && child.IsLeaf() {
+		for i, c := range n.children {
+			if c == child {
+				n.removeChild(i)
+				break
+			}
+		}
+	}
+
+	return true
 }
 
 // IsMember checks if the given phrase is a member of this Phrase Trie tree
 // and returns the phrase value if true
-func (n *PhraseTrieNode) IsMember(phrase []string) (bool, int) {
-	for _, child := range n.children {
-		if phrase[0] == child.key {
-			if len(phrase) != 1 {
-				if child.IsLeaf() { // full phrase not member
-					return false, 0
-				}
-
-				return child.IsMember(phrase[1:])
-			} else if child.IsLeaf() { // match
-				return true, child.value
-			}
+func (n *PhraseTrie[V]) IsMember(phrase []string) (bool, V) {
+	var zero V
+
+	phrase = n.normalizePhrase(phrase)
+	if len(phrase) == 0 {
+		return false, zero
+	}
+
+	if node := n.find(phrase); node != nil && node.endsPhrase(n.config().leafOnly) { // match
+		return true, node.value
+	}
 
-			// not a leaf, no match
-			return false, 0
+	return false, zero
+}
+
+// find follows the path of keys down from this node
+// Returns the node at the end of the path, or nil if the path is not in this Trie
+func (n *PhraseTrie[V]) find(path []string) *PhraseTrie[V] {
+	node := n
+	for _, key := range path {
+		if node = node.child(key); node == nil { // dead end
+			return nil
 		}
 	}
 
-	return false, 0
+	return node
 }
 
-// FindMember recursively traverses this Trie to find if the given
+// FindMember traverses this Trie to find if the given
 // sequence begins with a member phrase
 //
 // Returns the a bool valid if the parts found were a valid
 // full member phrase, the found phrase, and its value
 //
-// A valid member phrase ends its search on a leaf node, i.e. a full phrase
+// A valid member phrase ends its search on a terminal node, i.e. a full phrase
+// (or on a leaf node in leaf-only mode)
 //
-// If there are multiple member phrases in the sequence FindMember only
-// finds and returns the FIRST found phrase
-func (n *PhraseTrieNode) FindMember(sequence []string) (bool, []string, int) {
+// If there are multiple member phrases at the head of the sequence FindMember
+// returns the LONGEST one. The search follows the sequence as deep as the Trie
+// allows and backtracks to the last terminal node it passed, so a trie holding
+// "break out" and "break out nicely" finds "break out" in "break out today"
+//
+// Wildcards match the words of the sequence, and the returned phrase
+// holds the matched words, e.g. "break the resistance" for "break * resistance"
+func (n *PhraseTrie[V]) FindMember(sequence []string) (bool, []string, V) {
+	var value V
+
+	length, end, _ := n.longest(n.normalizeSentence(sequence))
+	if end != nil {
+		value = end.value
+	}
+
+	phrase := make([]string, length)
+	copy(phrase, sequence[:length])
+
+	return end != nil, phrase, value
+}
+
+// longest returns the length, end node and stored phrase with wildcards
+// of the longest member phrase the sequence begins with
+// The end node is nil if there is none, the stored phrase is nil on exact matches
+func (n *PhraseTrie[V]) longest(sequence []string) (int, *PhraseTrie[V], []string) {
 	var (
-		phrase []string
-		valid  bool
-		value  int
+		length  int
+		end     *PhraseTrie[V]
+		pattern []string
 	)
 
-	phrase = make([]string, 0)
+	n.matchPrefixes(sequence, func(l int, node *PhraseTrie[V], p []string) {
+		if l > length { // keep longest phrase so far
+			length = l
+			end = node
+			pattern = append(pattern[:0], p...)
+		}
+	})
 
-	for _, child := range n.children {
-		if child.key == sequence[0] {
-			if child.IsLeaf() { // found phrase
-				valid = true
-				value = child.value
-				phrase = append(phrase, child.key)
-				break
-			} else if len(sequence) != 1 { // recur down trie
-				// first add this child's key to the phrase
-				phrase = append(phrase, child.key)
+	if len(pattern) == 0 {
+		pattern = nil
+	}
 
-				// recur down matched child
-				childValid, childPhrase, childValue := child.FindMember(sequence[1:])
+	return length, end, pattern
+}
 
-				valid = childValid
-				value = childValue
+// FindMembersAt traverses this Trie to find ALL member phrases
+// the given sequence begins with, e.g. "break", "break out" and
+// "break out nicely" for the sequence "break out nicely today"
+//
+// Returns a PCtxList ordered from the shortest to the longest phrase
+// with indices into the given sequence
+// An empty (len == 0) list constitutes no member phrases at the head of the sequence
+func (n *PhraseTrie[V]) FindMembersAt(sequence []string) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+	words := n.normalizeSentence(sequence)
 
-				for _, p := range childPhrase {
-					phrase = append(phrase, p)
-				}
+	n.matchPrefixes(words, func(l int, end *PhraseTrie[V], pattern []string) {
+		phrase := make([]string, l)
+		copy(phrase, words[:l])
 
-				break
-			} else { // not valid phrase
-				break
-			}
+		pc := NewPhraseContext(phrase, words, []int{0, l - 1}, end.value)
+		if pattern != nil {
+			pc.Pattern = append([]string(nil), pattern...)
 		}
+
+		foundMembers = append(foundMembers, n.restore(pc, sequence))
+	})
+
+	if n.config().wildcards { // wildcard paths are not found in length order
+		sort.SliceStable(foundMembers, func(i, j int) bool {
+			return len(foundMembers[i].Phrase) < len(foundMembers[j].Phrase)
+		})
 	}
 
-	return valid, phrase, value
+	return foundMembers
+}
+
+// matchPrefixes follows the sequence down this Trie as deep as it allows
+// and calls fn with the length and end node of every member phrase
+// the sequence begins with, from shortest to longest
+// If this Trie holds wildcards fn also gets the stored phrase, see matchWildcards
+func (n *PhraseTrie[V]) matchPrefixes(sequence []string, fn func(length int, end *PhraseTrie[V], pattern []string)) {
+	if n.config().wildcards {
+		n.matchWildcards(sequence, fn)
+		return
+	}
+
+	leafOnly := n.config().leafOnly
+
+	node := n
+	for i, word := range sequence {
+		if node = node.child(word); node == nil { // dead end
+			return
+		}
+
+		if node.endsPhrase(leafOnly) {
+			fn(i+1, node, nil)
+		}
+	}
 }
 
 // FindAllMembers iterates in a linear sequential fashion through a sentence
@@ -152,26 +427,216 @@ func (n *PhraseTrieNode) FindMember(sequence []string) (bool, []string, int) {
 // of this Trie.
 // Returns a map containing the phrase string and its value
 // An empty (len == 0) map consitutes no valid member phrases found in the given sentence
-func (n *PhraseTrieNode) FindAllMembers(sentence []string) PCtxList {
-	foundMembers := make(PCtxList, 0)
+func (n *PhraseTrie[V]) FindAllMembers(sentence []string) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+	words := n.normalizeSentence(sentence)
 
-	for i := 0; i < len(sentence); i++ {
+	for i := 0; i < len(words); i++ {
 		if n.IsLeaf() { // no children to match
 			return nil
 		}
 
-		valid, p, v := n.FindMember(sentence[i:])
+		length, end, pattern := n.longest(words[i:])
+
+		if end != nil { // valid phrase was found
+			phrase := make([]string, length)
+			copy(phrase, words[i:i+length])
 
-		if valid { // valid phrase was found
-			foundMembers = append(foundMembers, NewPhraseContext(p, sentence, []int{i, i + len(p) - 1}, v))
+			pc := NewPhraseContext(phrase, words, []int{i, i + length - 1}, end.value)
+			pc.Pattern = pattern
+
+			foundMembers = append(foundMembers, n.restore(pc, sentence))
 		}
 	}
 
 	return foundMembers
 }
 
+// Walk calls fn with every member phrase of this Trie and its value, in
+// lexicographic order by token, so a phrase comes before the longer phrases
+// it begins. Walk stops early if fn returns false
+// fn gets its own copy of each phrase and must not modify this Trie
+func (n *PhraseTrie[V]) Walk(fn func(phrase []string, value V) bool) {
+	n.walk(make([]string, 0, 8), n.config().leafOnly, fn)
+}
+
+// walk recursively calls fn with the member phrases below this node,
+// where phrase is the path to this node. Returns false if fn stopped the walk
+func (n *PhraseTrie[V]) walk(phrase []string, leafOnly bool, fn func([]string, V) bool) bool {
+	for _, child := range n.sortedChildren() {
+		path := append(phrase, child.key)
+
+		if child.endsPhrase(leafOnly) {
+			member := make([]string, len(path))
+			copy(member, path)
+
+			if !fn(member, child.value) {
+				return false
+			}
+		}
+
+		if !child.walk(path, leafOnly, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// All returns an iterator over every member phrase of this Trie and its
+// value, in the same order as Walk
+func (n *PhraseTrie[V]) All() iter.Seq2[[]string, V] {
+	return n.Walk
+}
+
+// A PhraseEntry is a member phrase of a Trie and its value
+type PhraseEntry[V any] struct {
+	Phrase []string
+	Value  V
+}
+
+// WithPrefix returns the member phrases of this Trie that begin with the
+// given prefix, including the prefix itself if it is a member, e.g. to
+// autocomplete "break out" to "break out" and "break out nicely"
+//
+// Phrases are in lexicographic order by token, like Walk, or ordered by value
+// with less if it is not nil, keeping lexicographic order between equal values
+// If limit is greater than 0 at most limit phrases are returned
+func (n *PhraseTrie[V]) WithPrefix(prefix []string, limit int, less func(a, b V) bool) []PhraseEntry[V] {
+	prefix = n.normalizePhrase(prefix)
+
+	node := n.find(prefix)
+	if node == nil {
+		return nil
+	}
+
+	var entries []PhraseEntry[V]
+
+	add := func(phrase []string, value V) bool {
+		entries = append(entries, PhraseEntry[V]{phrase, value})
+
+		// lexicographic order can stop at the limit
+		return less != nil || limit <= 0 || len(entries) < limit
+	}
+
+	path := make([]string, len(prefix))
+	copy(path, prefix)
+
+	if len(prefix) != 0 && node.endsPhrase(n.config().leafOnly) && !add(path, node.value) {
+		return entries
+	}
+
+	node.walk(path, n.config().leafOnly, add)
+
+	if less != nil {
+		sort.SliceStable(entries, func(a, b int) bool {
+			return less(entries[a].Value, entries[b].Value)
+		})
+
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+	}
+
+	return entries
+}
+
+// child returns the child node with the given key, or nil if there is none
+// Uses the child index on high fanout nodes, otherwise scans the children
+func (n *PhraseTrie[V]) child(key string) *PhraseTrie[V] {
+	if index := n.childIndex(); index != nil {
+		return index[key]
+	}
+
+	for _, child := range n.children {
+		if child.key == key {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// addChild appends a child node, indexing the children once
+// this node passes childIndexThreshold
+func (n *PhraseTrie[V]) addChild(child *PhraseTrie[V]) {
+	n.children = append(n.children, child)
+
+	if index := n.childIndex(); index != nil {
+		index[child.key] = child
+	} else if len(n.children) > childIndexThreshold {
+		index = make(map[string]*PhraseTrie[V], len(n.children))
+		for _, c := range n.children {
+			index[c.key] = c
+		}
+
+		n.setChildIndex(index)
+	}
+}
+
+// removeChild removes the child node at position i, preserving the
+// order of the other children, and drops the child index once this
+// node is back under childIndexThreshold
+func (n *PhraseTrie[V]) removeChild(i int) {
+	if index := n.childIndex(); index != nil {
+		delete(index, n.children[i].key)
+	}
+
+	copy(n.children[i:], n.children[i+1:])
+	n.children[len(n.children)-1] = nil
+	n.children = n.children[:len(n.children)-1]
+
+	if len(n.children) <= childIndexThreshold {
+		n.setChildIndex(nil)
+	}
+}
+
+// childIndex returns the children of this node by key,
+// or nil if this node is not indexed
+func (n *PhraseTrie[V]) childIndex() map[string]*PhraseTrie[V] {
+	if n.ext == nil {
+		return nil
+	}
+
+	return n.ext.index
+}
+
+// setChildIndex sets or, if index is nil, drops the child index of this node
+func (n *PhraseTrie[V]) setChildIndex(index map[string]*PhraseTrie[V]) {
+	switch {
+	case n.ext != nil:
+		n.ext.index = index
+		if index == nil && n.ext.root == nil {
+			n.ext = nil
+		}
+	case index != nil:
+		n.ext = &nodeExt[V]{index: index}
+	}
+}
+
+// sortedChildren returns a copy of the children of this node sorted by key
+func (n *PhraseTrie[V]) sortedChildren() []*PhraseTrie[V] {
+	children := make([]*PhraseTrie[V], len(n.children))
+	copy(children, n.children)
+	sort.Slice(children, func(a, b int) bool {
+		return children[a].key < children[b].key
+	})
+
+	return children
+}
+
+// endsPhrase returns true if this node ends a member phrase
+// In leaf-only mode only leaves end a phrase, otherwise terminal nodes do
+func (n *PhraseTrie[V]) endsPhrase(leafOnly bool) bool {
+	if leafOnly {
+		return n.IsLeaf()
+	}
+
+	return n.terminal
+}
+
 // IsLeaf returns true if this node is a leaf
 // A node is a leaf when it has no children
-func (n *PhraseTrieNode) IsLeaf() bool {
+func (n *PhraseTrie[V]) IsLeaf() bool {
 	return n.children == nil || len(n.children) == 0
 }
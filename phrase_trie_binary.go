@@ -0,0 +1,495 @@
+package trie
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"reflect"
+)
+
+/* BINARY SERIALIZATION */
+
+// The binary format of a PhraseTrie is
+//	magic     4 bytes "PTRI"
+//	version   1 byte
+//	flags     1 byte, bit 0 set in leaf-only mode
+//	cap       uvarint wildcard cap, 0 for DefaultWildcardCap, see SetWildcardCap
+//	nodes     the root node followed by its children, depth first in child order
+//	checksum  4 bytes little endian CRC-32 (IEEE) of everything before it
+//
+// where each node is
+//	key       uvarint length followed by the key bytes
+//	terminal  1 byte, 1 if the node ends a member phrase
+//	value     the encoded value, only if terminal
+//	children  uvarint number of children
+//
+// Values are encoded by type: ints as varints, uints as uvarints, floats as
+// 8 byte little endian IEEE 754 bits, bools as 1 byte, strings and []byte as
+// a uvarint length followed by the bytes, and types implementing
+// encoding.BinaryMarshaler as a uvarint length followed by their encoding.
+// Pointer values are encoded as the value they point to and decoded into a
+// newly allocated value, so they must not be nil. Other value types, such as
+// plain structs, return ErrUnsupportedValue and should implement
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+//
+// Version 1 had no wildcard cap and is still decoded
+
+const (
+	binaryMagic   = "PTRI"
+	binaryVersion = 2
+
+	binaryFlagLeafOnly = 1 << 0
+)
+
+var (
+	// ErrInvalidFormat is returned when decoding data that is not an encoded PhraseTrie
+	ErrInvalidFormat = errors.New("trie: invalid binary format")
+
+	// ErrUnsupportedVersion is returned when decoding a newer binary format version
+	ErrUnsupportedVersion = errors.New("trie: unsupported binary format version")
+
+	// ErrChecksum is returned when decoding data whose checksum does not match
+	ErrChecksum = errors.New("trie: binary checksum mismatch")
+
+	// ErrUnsupportedValue is returned when encoding or decoding a PhraseTrie
+	// whose value type has no binary encoding
+	ErrUnsupportedValue = errors.New("trie: unsupported value type")
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler
+// Encodes this Trie, including its values, node order, membership mode
+// and wildcard cap
+func (n *PhraseTrie[V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(binaryMagic)
+	buf.WriteByte(binaryVersion)
+
+	var flags byte
+	if n.config().leafOnly {
+		flags |= binaryFlagLeafOnly
+	}
+	buf.WriteByte(flags)
+	writeUvarint(&buf, uint64(n.config().wildcardCap))
+
+	if err := checkDecodable[V](); err != nil {
+		return nil, err
+	}
+	if err := n.encode(&buf); err != nil {
+		return nil, err
+	}
+
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(buf.Bytes()))
+	buf.Write(sum[:])
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// Replaces the contents of this Trie with the decoded Trie, keeping the
+// Normalizer and Tokenizer of this Trie, which are not part of the encoding
+func (n *PhraseTrie[V]) UnmarshalBinary(data []byte) error {
+	header := len(binaryMagic) + 2
+	if len(data) < header+4 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return ErrInvalidFormat
+	}
+
+	version := data[len(binaryMagic)]
+	if version < 1 || version > binaryVersion {
+		return ErrUnsupportedVersion
+	}
+
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return ErrChecksum
+	}
+
+	d := &decoder{data: body, pos: header}
+
+	var wildcardCap uint64
+	if version >= 2 {
+		var err error
+		if wildcardCap, err = d.uvarint(); err != nil {
+			return err
+		}
+
+		if wildcardCap > math.MaxInt32 {
+			return ErrInvalidFormat
+		}
+	}
+
+	root := &PhraseTrie[V]{}
+	if err := root.decode(d); err != nil {
+		return err
+	}
+
+	if d.pos != len(body) { // trailing bytes
+		return ErrInvalidFormat
+	}
+
+	leafOnly := data[len(binaryMagic)+1]&binaryFlagLeafOnly != 0
+	wildcards := root.containsWildcards()
+	if leafOnly || wildcards || wildcardCap != 0 {
+		config := root.setConfig()
+		config.leafOnly, config.wildcards, config.wildcardCap = leafOnly, wildcards, int32(wildcardCap)
+	}
+
+	// the Normalizer and Tokenizer are not encoded, keep those of this Trie
+	if old := n.config(); old.normalizer != nil || old.tokenizer != nil {
+		config := root.setConfig()
+		config.normalizer, config.tokenizer = old.normalizer, old.tokenizer
+	}
+
+	*n = *root
+
+	return nil
+}
+
+// WriteTo implements io.WriterTo, writing the binary encoding of this Trie to w
+func (n *PhraseTrie[V]) WriteTo(w io.Writer) (int64, error) {
+	data, err := n.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := w.Write(data)
+
+	return int64(written), err
+}
+
+// ReadFrom implements io.ReaderFrom, reading a binary encoded Trie from r
+// until EOF and replacing the contents of this Trie with it
+func (n *PhraseTrie[V]) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+
+	return int64(len(data)), n.UnmarshalBinary(data)
+}
+
+// encode recursively writes this node and its children to buf
+func (n *PhraseTrie[V]) encode(buf *bytes.Buffer) error {
+	writeUvarint(buf, uint64(len(n.key)))
+	buf.WriteString(n.key)
+
+	if n.terminal {
+		buf.WriteByte(1)
+		if err := encodeValue(buf, n.value); err != nil {
+			return err
+		}
+	} else {
+		buf.WriteByte(0)
+	}
+
+	writeUvarint(buf, uint64(len(n.children)))
+	for _, child := range n.children {
+		if err := child.encode(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decode recursively reads this node and its children from d
+func (n *PhraseTrie[V]) decode(d *decoder) error {
+	key, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	n.key = string(key)
+
+	terminal, err := d.byte()
+	if err != nil {
+		return err
+	}
+
+	switch terminal {
+	case 0:
+	case 1:
+		n.terminal = true
+		if err := decodeValue(d, &n.value); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidFormat
+	}
+
+	count, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+
+	if count > uint64(len(d.data)-d.pos) { // every child takes at least 1 byte
+		return ErrInvalidFormat
+	}
+
+	for i := uint64(0); i < count; i++ {
+		child := &PhraseTrie[V]{}
+		if err := child.decode(d); err != nil {
+			return err
+		}
+
+		n.addChild(child)
+	}
+
+	return nil
+}
+
+// encodeValue writes a single phrase value to buf
+func encodeValue[V any](buf *bytes.Buffer, value V) error {
+	switch v := any(value).(type) {
+	case int:
+		writeVarint(buf, int64(v))
+	case int8:
+		writeVarint(buf, int64(v))
+	case int16:
+		writeVarint(buf, int64(v))
+	case int32:
+		writeVarint(buf, int64(v))
+	case int64:
+		writeVarint(buf, v)
+	case uint:
+		writeUvarint(buf, uint64(v))
+	case uint8:
+		writeUvarint(buf, uint64(v))
+	case uint16:
+		writeUvarint(buf, uint64(v))
+	case uint32:
+		writeUvarint(buf, uint64(v))
+	case uint64:
+		writeUvarint(buf, v)
+	case float32:
+		writeFloat(buf, float64(v))
+	case float64:
+		writeFloat(buf, v)
+	case bool:
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case string:
+		writeUvarint(buf, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		writeUvarint(buf, uint64(len(v)))
+		buf.Write(v)
+	case encoding.BinaryMarshaler:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return fmt.Errorf("%w: nil %T", ErrUnsupportedValue, value)
+		}
+
+		return encodeMarshaler(buf, v)
+	default:
+		if m, ok := any(&value).(encoding.BinaryMarshaler); ok { // pointer receiver
+			return encodeMarshaler(buf, m)
+		}
+
+		return fmt.Errorf("%w: %T", ErrUnsupportedValue, value)
+	}
+
+	return nil
+}
+
+// encodeMarshaler writes the length prefixed binary encoding of m to buf
+func encodeMarshaler(buf *bytes.Buffer, m encoding.BinaryMarshaler) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	writeUvarint(buf, uint64(len(data)))
+	buf.Write(data)
+
+	return nil
+}
+
+// decodeValue reads a single phrase value from d into value
+func decodeValue[V any](d *decoder, value *V) error {
+	var err error
+
+	switch v := any(value).(type) {
+	case *int:
+		var x int64
+		x, err = d.varint()
+		*v = int(x)
+	case *int8:
+		var x int64
+		x, err = d.varint()
+		*v = int8(x)
+	case *int16:
+		var x int64
+		x, err = d.varint()
+		*v = int16(x)
+	case *int32:
+		var x int64
+		x, err = d.varint()
+		*v = int32(x)
+	case *int64:
+		*v, err = d.varint()
+	case *uint:
+		var x uint64
+		x, err = d.uvarint()
+		*v = uint(x)
+	case *uint8:
+		var x uint64
+		x, err = d.uvarint()
+		*v = uint8(x)
+	case *uint16:
+		var x uint64
+		x, err = d.uvarint()
+		*v = uint16(x)
+	case *uint32:
+		var x uint64
+		x, err = d.uvarint()
+		*v = uint32(x)
+	case *uint64:
+		*v, err = d.uvarint()
+	case *float32:
+		var x float64
+		x, err = d.float()
+		*v = float32(x)
+	case *float64:
+		*v, err = d.float()
+	case *bool:
+		var b byte
+		b, err = d.byte()
+		*v = b == 1
+	case *string:
+		var b []byte
+		b, err = d.bytes()
+		*v = string(b)
+	case *[]byte:
+		var b []byte
+		b, err = d.bytes()
+		*v = append([]byte(nil), b...)
+	case encoding.BinaryUnmarshaler:
+		var b []byte
+		if b, err = d.bytes(); err == nil {
+			err = v.UnmarshalBinary(b)
+		}
+	default:
+		// a pointer to a type whose pointer implements encoding.BinaryUnmarshaler
+		t := reflect.TypeFor[V]()
+		if t.Kind() != reflect.Pointer {
+			return fmt.Errorf("%w: %T", ErrUnsupportedValue, *value)
+		}
+
+		ptr := reflect.New(t.Elem())
+		u, ok := ptr.Interface().(encoding.BinaryUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrUnsupportedValue, *value)
+		}
+
+		var b []byte
+		if b, err = d.bytes(); err == nil {
+			if err = u.UnmarshalBinary(b); err == nil {
+				*value = ptr.Interface().(V)
+			}
+		}
+	}
+
+	return err
+}
+
+// checkDecodable returns ErrUnsupportedValue if decodeValue cannot decode
+// values of type V, so that nothing is encoded that cannot be decoded again
+func checkDecodable[V any]() error {
+	var value V
+	if err := decodeValue(&decoder{}, &value); errors.Is(err, ErrUnsupportedValue) {
+		return err
+	}
+
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, x uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], x)])
+}
+
+func writeVarint(buf *bytes.Buffer, x int64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutVarint(scratch[:], x)])
+}
+
+func writeFloat(buf *bytes.Buffer, x float64) {
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(x))
+	buf.Write(scratch[:])
+}
+
+// decoder reads encoded values from data starting at pos
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, ErrInvalidFormat
+	}
+
+	b := d.data[d.pos]
+	d.pos++
+
+	return b, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	x, l := binary.Uvarint(d.data[d.pos:])
+	if l <= 0 {
+		return 0, ErrInvalidFormat
+	}
+
+	d.pos += l
+
+	return x, nil
+}
+
+func (d *decoder) varint() (int64, error) {
+	x, l := binary.Varint(d.data[d.pos:])
+	if l <= 0 {
+		return 0, ErrInvalidFormat
+	}
+
+	d.pos += l
+
+	return x, nil
+}
+
+func (d *decoder) float() (float64, error) {
+	if len(d.data)-d.pos < 8 {
+		return 0, ErrInvalidFormat
+	}
+
+	x := math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.pos:]))
+	d.pos += 8
+
+	return x, nil
+}
+
+// bytes reads a uvarint length prefixed byte slice, sharing d.data
+func (d *decoder) bytes() ([]byte, error) {
+	l, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	if l > uint64(len(d.data)-d.pos) {
+		return nil, ErrInvalidFormat
+	}
+
+	b := d.data[d.pos : d.pos+int(l)]
+	d.pos += int(l)
+
+	return b, nil
+}
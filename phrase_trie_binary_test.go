@@ -0,0 +1,264 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSentiment is a phrase value with its own binary encoding
+type mockSentiment struct {
+	Polarity   int8
+	Confidence uint8
+}
+
+func (s mockSentiment) MarshalBinary() ([]byte, error) {
+	return []byte{byte(s.Polarity), s.Confidence}, nil
+}
+
+func (s *mockSentiment) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("bad sentiment")
+	}
+
+	s.Polarity, s.Confidence = int8(data[0]), data[1]
+
+	return nil
+}
+
+// mockMarshalOnly is a phrase value that can be encoded but not decoded
+type mockMarshalOnly struct{}
+
+func (mockMarshalOnly) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+func TestMarshalBinary(t *testing.T) {
+	trie := mockTrieFull()
+	trie.Add([]string{"big", "drop"}, -42)
+
+	data, err := trie.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, "PTRI", string(data[:4]))
+	assert.Equal(t, byte(binaryVersion), data[4])
+	assert.Equal(t, byte(0), data[5])
+	assert.Equal(t, byte(0), data[6])
+
+	decoded := NewPhraseTrie(nil)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+
+	// exact round trip, including node order
+	assert.Equal(t, trie, decoded)
+
+	for _, s := range []string{"its shooting up it might even break up i bet $100 $AAPL will break out nicely", "big drop r/g"} {
+		sSplit := strings.Split(s, " ")
+		assert.Equal(t, trie.FindAllMembers(sSplit), decoded.FindAllMembers(sSplit))
+	}
+
+	// deterministic
+	again, err := decoded.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, data, again)
+
+	// leaf-only mode
+	trie.SetLeafOnly(true)
+	data, err = trie.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+	assert.True(t, decoded.config().leafOnly)
+
+	member, _ := decoded.IsMember([]string{"break", "out"})
+	assert.False(t, member)
+
+	// empty
+	data, err = NewPhraseTrie(nil).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+	assert.True(t, decoded.IsLeaf())
+	assert.False(t, decoded.config().leafOnly)
+}
+
+func TestMarshalBinaryWildcardCap(t *testing.T) {
+	trie := mockTrieWildcards()
+	trie.SetWildcardCap(1)
+
+	data, err := trie.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, byte(1), data[6])
+
+	decoded := NewPhraseTrie(nil)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, trie, decoded)
+
+	sentence := []string{"short", "the", "whole", "market", "today"}
+	valid, _, _ := decoded.FindMember(sentence)
+	assert.False(t, valid)
+
+	// version 1 has no cap
+	v1 := append([]byte{}, data[:len(data)-4]...)
+	v1 = append(v1[:6], v1[7:]...)
+	v1[4] = 1
+	v1 = binary.LittleEndian.AppendUint32(v1, crc32.ChecksumIEEE(v1))
+
+	assert.Nil(t, decoded.UnmarshalBinary(v1))
+	trie.SetWildcardCap(0)
+	assert.Equal(t, trie, decoded)
+}
+
+func TestMarshalBinaryLarge(t *testing.T) {
+	large, message := mockLexiconLarge()
+
+	data, err := large.MarshalBinary()
+	assert.Nil(t, err)
+
+	decoded := NewPhraseTrie(nil)
+	assert.Nil(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, large, decoded)
+	assert.Equal(t, large.FindAllMembers(message), decoded.FindAllMembers(message))
+}
+
+func TestMarshalBinaryValues(t *testing.T) {
+	phrases := []string{"break out", "shooting up", "double bottom"}
+
+	floats := NewPhraseTrieOf[float64](nil)
+	strs := NewPhraseTrieOf[string](nil)
+	bools := NewPhraseTrieOf[bool](nil)
+	uints := NewPhraseTrieOf[uint16](nil)
+	raw := NewPhraseTrieOf[[]byte](nil)
+	sentiments := NewPhraseTrieOf[mockSentiment](nil)
+	pointers := NewPhraseTrieOf[*mockSentiment](nil)
+
+	for i, p := range phrases {
+		phrase := strings.Split(p, " ")
+		floats.Add(phrase, float64(i)-0.25)
+		strs.Add(phrase, p)
+		bools.Add(phrase, i%2 == 0)
+		uints.Add(phrase, uint16(i*1000))
+		raw.Add(phrase, []byte(p))
+		sentiments.Add(phrase, mockSentiment{int8(i - 1), uint8(i * 10)})
+		pointers.Add(phrase, &mockSentiment{int8(i - 1), uint8(i * 10)})
+	}
+
+	roundTrip := func(src, dst interface {
+		MarshalBinary() ([]byte, error)
+		UnmarshalBinary([]byte) error
+	}) {
+		data, err := src.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Nil(t, dst.UnmarshalBinary(data))
+		assert.Equal(t, src, dst)
+	}
+
+	roundTrip(floats, NewPhraseTrieOf[float64](nil))
+	roundTrip(strs, NewPhraseTrieOf[string](nil))
+	roundTrip(bools, NewPhraseTrieOf[bool](nil))
+	roundTrip(uints, NewPhraseTrieOf[uint16](nil))
+	roundTrip(raw, NewPhraseTrieOf[[]byte](nil))
+	roundTrip(sentiments, NewPhraseTrieOf[mockSentiment](nil))
+	roundTrip(pointers, NewPhraseTrieOf[*mockSentiment](nil))
+
+	// nil pointer values
+	pointers.Add([]string{"dead", "cat"}, nil)
+
+	_, err := pointers.MarshalBinary()
+	assert.True(t, errors.Is(err, ErrUnsupportedValue))
+
+	// no binary encoding
+	type label struct{ Name string }
+	labels := NewPhraseTrieOf(map[string]label{"r/g": {"color"}})
+
+	_, err = labels.MarshalBinary()
+	assert.True(t, errors.Is(err, ErrUnsupportedValue))
+
+	// encodes but cannot be decoded
+	marshalers := NewPhraseTrieOf(map[string]mockMarshalOnly{"r/g": {}})
+
+	_, err = marshalers.MarshalBinary()
+	assert.True(t, errors.Is(err, ErrUnsupportedValue))
+
+	// wrong value type
+	data, err := strs.MarshalBinary()
+	assert.Nil(t, err)
+	assert.NotNil(t, NewPhraseTrieOf[mockSentiment](nil).UnmarshalBinary(data))
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	data, err := mockTrieFull().MarshalBinary()
+	assert.Nil(t, err)
+
+	trie := mockTrieFull()
+
+	// too short or not a trie
+	assert.Equal(t, ErrInvalidFormat, trie.UnmarshalBinary(nil))
+	assert.Equal(t, ErrInvalidFormat, trie.UnmarshalBinary([]byte("PTRI")))
+	assert.Equal(t, ErrInvalidFormat, trie.UnmarshalBinary(append([]byte("XXXX"), data[4:]...)))
+
+	// newer version
+	bad := append([]byte(nil), data...)
+	bad[4] = binaryVersion + 1
+	assert.Equal(t, ErrUnsupportedVersion, trie.UnmarshalBinary(bad))
+	bad[4] = 0
+	assert.Equal(t, ErrUnsupportedVersion, trie.UnmarshalBinary(bad))
+
+	// corrupted
+	bad = append([]byte(nil), data...)
+	bad[len(bad)/2] ^= 0xff
+	assert.Equal(t, ErrChecksum, trie.UnmarshalBinary(bad))
+
+	// truncated body with valid checksum
+	bad = append([]byte(nil), data[:len(data)/2]...)
+	sum := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sum, crc32.ChecksumIEEE(bad))
+	bad = append(bad, sum...)
+	assert.Equal(t, ErrInvalidFormat, trie.UnmarshalBinary(bad))
+
+	// failed decode leaves the trie untouched
+	member, value := trie.IsMember([]string{"break", "out", "nicely"})
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	trie := mockTrieFull()
+
+	var buf bytes.Buffer
+	written, err := trie.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), written)
+
+	decoded := NewPhraseTrie(nil)
+	read, err := decoded.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, written, read)
+	assert.Equal(t, trie, decoded)
+
+	// empty reader
+	_, err = decoded.ReadFrom(&buf)
+	assert.Equal(t, ErrInvalidFormat, err)
+}
+
+func BenchmarkMarshalBinary(b *testing.B) {
+	large, _ := mockLexiconLarge()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = large.MarshalBinary()
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	large, _ := mockLexiconLarge()
+	data, _ := large.MarshalBinary()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = NewPhraseTrie(nil).UnmarshalBinary(data)
+	}
+}
@@ -1,6 +1,7 @@
 package trie
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -8,7 +9,7 @@ import (
 )
 
 func mockPhraseTrieNode() *PhraseTrieNode {
-	return &PhraseTrieNode{key: "test", value: 1, children: []*PhraseTrieNode{}}
+	return &PhraseTrieNode{key: "test", value: 1, terminal: true, children: []*PhraseTrieNode{}}
 }
 
 func mockTrieFull() *PhraseTrieNode {
@@ -130,6 +131,10 @@ func TestIsMember(t *testing.T) {
 	n6.children[0].children[0].value = 6
 	trie.children = append(trie.children, n6)
 
+	// prefixes are not phrases
+	n6.terminal = false
+	n6.children[0].terminal = false
+
 	// test partial phrase, should be false
 	member, value = trie.IsMember([]string{"break"})
 	assert.False(t, member)
@@ -192,9 +197,10 @@ func TestAdd(t *testing.T) {
 	assert.True(t, member)
 	assert.Equal(t, 3, value)
 
-	// check p1, should fail
+	// check p1, should still be true
 	member, value = trie.IsMember(p1)
-	assert.False(t, member)
+	assert.True(t, member)
+	assert.Equal(t, 1, value)
 
 	// add p4
 	trie.Add(p4, 4)
@@ -230,9 +236,10 @@ func TestAdd(t *testing.T) {
 	// add p6, 3 level phrase
 	trie.Add(p6, 6)
 
-	// check p3, should be false now bc break out if a prefix to p6
+	// check p3, should still be true even though break out is a prefix to p6
 	member, value = trie.IsMember(p3)
-	assert.False(t, member)
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
 
 	// check p4, should still be true
 	member, value = trie.IsMember(p4)
@@ -255,6 +262,346 @@ func TestAdd(t *testing.T) {
 	member, value = trie.IsMember(p7)
 	assert.True(t, member)
 	assert.Equal(t, 7, value)
+
+	// adding an existing prefix makes it a phrase
+	trie = NewPhraseTrie(nil)
+	trie.Add(p6, 6)
+
+	member, value = trie.IsMember(p3)
+	assert.False(t, member)
+
+	trie.Add(p3, 3)
+
+	member, value = trie.IsMember(p3)
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	// existing phrase keeps its value
+	trie.Add(p3, 30)
+
+	member, value = trie.IsMember(p3)
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+}
+
+func TestSet(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+
+	p3 := []string{"break", "out"}
+	p6 := []string{"break", "out", "nicely"}
+
+	// new phrase
+	prev, existed := trie.Set(p6, 6)
+	assert.False(t, existed)
+	assert.Equal(t, 0, prev)
+
+	member, value := trie.IsMember(p6)
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+
+	// existing prefix is not a phrase yet
+	prev, existed = trie.Set(p3, 3)
+	assert.False(t, existed)
+	assert.Equal(t, 0, prev)
+
+	// overwrite
+	prev, existed = trie.Set(p3, 30)
+	assert.True(t, existed)
+	assert.Equal(t, 3, prev)
+
+	member, value = trie.IsMember(p3)
+	assert.True(t, member)
+	assert.Equal(t, 30, value)
+
+	// longer phrase untouched
+	member, value = trie.IsMember(p6)
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+
+	// removed phrase no longer exists
+	trie.Remove(p3)
+	prev, existed = trie.Set(p3, 3)
+	assert.False(t, existed)
+	assert.Equal(t, 0, prev)
+
+	// empty phrase
+	prev, existed = trie.Set(nil, 1)
+	assert.False(t, existed)
+	assert.False(t, trie.terminal)
+}
+
+func TestUpdate(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+	p := []string{"shooting", "up"}
+
+	// accumulate values from several lexicons
+	sum := func(v int) func(int, bool) int {
+		return func(old int, ok bool) int {
+			return old + v
+		}
+	}
+
+	assert.Equal(t, 5, trie.Update(p, sum(5)))
+	assert.Equal(t, 7, trie.Update(p, sum(2)))
+
+	member, value := trie.IsMember(p)
+	assert.True(t, member)
+	assert.Equal(t, 7, value)
+
+	// ok reports if the phrase existed
+	var seen []bool
+	record := func(old int, ok bool) int {
+		seen = append(seen, ok)
+		return old * 2
+	}
+
+	trie.Update([]string{"shooting"}, record)
+	trie.Update(p, record)
+	assert.Equal(t, []bool{false, true}, seen)
+
+	member, value = trie.IsMember(p)
+	assert.True(t, member)
+	assert.Equal(t, 14, value)
+
+	member, value = trie.IsMember([]string{"shooting"})
+	assert.True(t, member)
+	assert.Equal(t, 0, value)
+
+	// re-weight float values
+	weights := NewPhraseTrieOf(map[string]float64{"break out": 0.5})
+	weights.Update([]string{"break", "out"}, func(old float64, ok bool) float64 {
+		return old * 1.5
+	})
+
+	member, weight := weights.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 0.75, weight)
+}
+
+func TestSetLeafOnly(t *testing.T) {
+	trie := mockTrieFull()
+	trie.SetLeafOnly(true)
+
+	// prefixes of longer phrases are not members
+	member, _ := trie.IsMember([]string{"break"})
+	assert.False(t, member)
+
+	member, _ = trie.IsMember([]string{"break", "out"})
+	assert.False(t, member)
+
+	member, _ = trie.IsMember([]string{"shooting"})
+	assert.False(t, member)
+
+	// leaves are
+	member, value := trie.IsMember([]string{"break", "out", "nicely"})
+	assert.True(t, member)
+	assert.Equal(t, 6, value)
+
+	member, value = trie.IsMember([]string{"r/g"})
+	assert.True(t, member)
+	assert.Equal(t, 7, value)
+
+	valid, phrase, _ := trie.FindMember([]string{"break", "out"})
+	assert.False(t, valid)
+	assert.Equal(t, 0, len(phrase))
+
+	valid, phrase, value = trie.FindMember([]string{"break", "up", "today"})
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "up"}, phrase)
+	assert.Equal(t, 4, value)
+
+	phrases := trie.FindAllMembers(strings.Split("$AAPL isn't gonna break today", " "))
+	assert.Equal(t, 0, len(phrases))
+
+	// back to terminal membership
+	trie.SetLeafOnly(false)
+
+	member, value = trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+}
+
+func TestRemove(t *testing.T) {
+	trie := mockTrieFull()
+
+	// not members
+	assert.False(t, trie.Remove([]string{"$AAPL"}))
+	assert.False(t, trie.Remove([]string{"break", "down"}))
+	assert.False(t, trie.Remove([]string{"breaking", "double"}))
+	assert.False(t, trie.Remove(nil))
+
+	// remove leaf phrase, shared prefixes remain
+	assert.True(t, trie.Remove([]string{"break", "out", "nicely"}))
+
+	member, _ := trie.IsMember([]string{"break", "out", "nicely"})
+	assert.False(t, member)
+
+	member, value := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	member, value = trie.IsMember([]string{"break"})
+	assert.True(t, member)
+	assert.Equal(t, 1, value)
+
+	// nicely pruned
+	for _, child := range trie.children {
+		if child.key == "break" {
+			for _, c := range child.children {
+				if c.key == "out" {
+					assert.True(t, c.IsLeaf())
+				}
+			}
+		}
+	}
+
+	// already removed
+	assert.False(t, trie.Remove([]string{"break", "out", "nicely"}))
+
+	// remove prefix phrase, longer phrases remain
+	assert.True(t, trie.Remove([]string{"shooting"}))
+
+	member, _ = trie.IsMember([]string{"shooting"})
+	assert.False(t, member)
+
+	member, value = trie.IsMember([]string{"shooting", "up"})
+	assert.True(t, member)
+	assert.Equal(t, 5, value)
+
+	// removing the last phrase below a prefix prunes the whole branch
+	assert.True(t, trie.Remove([]string{"shooting", "up"}))
+
+	for _, child := range trie.children {
+		assert.NotEqual(t, "shooting", child.key)
+	}
+
+	// multi level prune keeps siblings
+	assert.True(t, trie.Remove([]string{"breaking", "double", "bottom"}))
+
+	member, value = trie.IsMember([]string{"double", "bottom"})
+	assert.True(t, member)
+	assert.Equal(t, 9, value)
+
+	for _, child := range trie.children {
+		assert.NotEqual(t, "breaking", child.key)
+	}
+
+	// remove everything
+	for _, p := range []string{"break", "break out", "break up", "r/g", "double bottom"} {
+		assert.True(t, trie.Remove(strings.Split(p, " ")))
+	}
+
+	assert.True(t, trie.IsLeaf())
+	assert.Equal(t, 0, len(trie.FindAllMembers([]string{"break", "out"})))
+}
+
+func TestChildIndex(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+
+	// low fanout, no index
+	for i := 0; i < childIndexThreshold; i++ {
+		trie.Add([]string{fmt.Sprintf("$T%d", i), "up"}, i)
+	}
+
+	assert.Nil(t, trie.childIndex())
+	assert.Equal(t, childIndexThreshold, len(trie.children))
+
+	// high fanout, indexed
+	for i := childIndexThreshold; i < 100; i++ {
+		trie.Add([]string{fmt.Sprintf("$T%d", i), "up"}, i)
+	}
+
+	assert.NotNil(t, trie.childIndex())
+	assert.Equal(t, 100, len(trie.childIndex()))
+	assert.Equal(t, 100, len(trie.children))
+
+	// children keep insertion order
+	for i, child := range trie.children {
+		assert.Equal(t, fmt.Sprintf("$T%d", i), child.key)
+	}
+
+	for i := 0; i < 100; i++ {
+		member, value := trie.IsMember([]string{fmt.Sprintf("$T%d", i), "up"})
+		assert.True(t, member)
+		assert.Equal(t, i, value)
+	}
+
+	member, _ := trie.IsMember([]string{"$T100", "up"})
+	assert.False(t, member)
+
+	phrases := trie.FindAllMembers(strings.Split("$T42 up and $T7 up then $T100 up", " "))
+	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, 42, phrases[0].Value)
+	assert.Equal(t, 7, phrases[1].Value)
+
+	// existing phrase found through the index
+	prev, existed := trie.Set([]string{"$T50", "up"}, 500)
+	assert.True(t, existed)
+	assert.Equal(t, 50, prev)
+	assert.Equal(t, 100, len(trie.children))
+
+	// removal keeps index in sync and drops it under the threshold
+	assert.True(t, trie.Remove([]string{"$T50", "up"}))
+	assert.Equal(t, 99, len(trie.childIndex()))
+	assert.Nil(t, trie.childIndex()["$T50"])
+
+	member, _ = trie.IsMember([]string{"$T50", "up"})
+	assert.False(t, member)
+
+	for i := 0; i < 100; i++ {
+		trie.Remove([]string{fmt.Sprintf("$T%d", i), "up"})
+		if len(trie.children) <= childIndexThreshold {
+			assert.Nil(t, trie.childIndex())
+		}
+	}
+
+	assert.True(t, trie.IsLeaf())
+}
+
+func TestPhraseTrieOf(t *testing.T) {
+	// float weights
+	weights := NewPhraseTrieOf(map[string]float64{
+		"break out":     0.75,
+		"double bottom": -0.5,
+	})
+
+	member, weight := weights.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 0.75, weight)
+
+	phrases := weights.FindAllMembers(strings.Split("its a double bottom, will break out", " "))
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, "break out", phrases[0].PhraseStr())
+	assert.Equal(t, 0.75, phrases[0].Value)
+
+	// struct values
+	type sentiment struct {
+		Polarity   int
+		Confidence float64
+	}
+
+	trie := NewPhraseTrieOf[sentiment](nil)
+	trie.Add([]string{"shooting", "up"}, sentiment{1, 0.9})
+	trie.Add([]string{"breaking", "double", "bottom"}, sentiment{-1, 0.6})
+
+	valid, phrase, value := trie.FindMember([]string{"shooting", "up", "today"})
+	assert.True(t, valid)
+	assert.Equal(t, []string{"shooting", "up"}, phrase)
+	assert.Equal(t, sentiment{1, 0.9}, value)
+
+	// category labels
+	labels := NewPhraseTrieOf(map[string]string{"r/g": "color", "double bottom": "pattern"})
+
+	found := labels.FindAllMembers(strings.Split("r/g double bottom", " ")).SuperOnly()
+	assert.Equal(t, 2, len(found))
+	assert.Equal(t, "color", found[0].Value)
+	assert.Equal(t, "pattern", found[1].Value)
+
+	assert.True(t, labels.Remove([]string{"r/g"}))
+
+	member, label := labels.IsMember([]string{"r/g"})
+	assert.False(t, member)
+	assert.Equal(t, "", label)
 }
 
 func TestFindMember(t *testing.T) {
@@ -312,6 +659,93 @@ func TestFindMember(t *testing.T) {
 	assert.True(t, valid)
 	assert.Equal(t, 1, len(phrase))
 	assert.Equal(t, 7, value)
+
+	// true, prefix phrase at end of sequence
+	trie.Add([]string{"break", "out"}, 3)
+	s4 := "break out"
+	valid, phrase, value = trie.FindMember(strings.Split(s4, " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out"}, phrase)
+	assert.Equal(t, 3, value)
+
+	// true, backtrack to prefix phrase when the longer phrase dead ends
+	s4 = "break out today"
+	valid, phrase, value = trie.FindMember(strings.Split(s4, " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out"}, phrase)
+	assert.Equal(t, 3, value)
+
+	// true, longest phrase wins
+	s4 = "break out nicely"
+	valid, phrase, value = trie.FindMember(strings.Split(s4, " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrase)
+	assert.Equal(t, 6, value)
+
+	// true, backtrack past several non terminal nodes
+	trie.Add([]string{"break", "out", "nicely", "and", "fast"}, 8)
+	s4 = "break out nicely and slow"
+	valid, phrase, value = trie.FindMember(strings.Split(s4, " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrase)
+	assert.Equal(t, 6, value)
+
+	// false, no terminal node passed
+	s4 = "break down"
+	valid, phrase, value = trie.FindMember(strings.Split(s4, " "))
+	assert.False(t, valid)
+	assert.Equal(t, 0, len(phrase))
+	assert.Equal(t, 0, value)
+
+	// false, empty sequence
+	valid, phrase, _ = trie.FindMember(nil)
+	assert.False(t, valid)
+	assert.Equal(t, 0, len(phrase))
+}
+
+func TestFindMembersAt(t *testing.T) {
+	trie := mockTrieFull()
+
+	// nothing
+	phrases := trie.FindMembersAt(strings.Split("$AAPL will break out nicely", " "))
+	assert.Equal(t, 0, len(phrases))
+
+	phrases = trie.FindMembersAt(nil)
+	assert.Equal(t, 0, len(phrases))
+
+	// all nested phrases, shortest first
+	s := strings.Split("break out nicely today $AAPL", " ")
+	phrases = trie.FindMembersAt(s)
+	assert.Equal(t, 3, len(phrases))
+	assert.Equal(t, []int{0, 0}, phrases[0].Indices)
+	assert.Equal(t, "break", phrases[0].PhraseStr())
+	assert.Equal(t, 1, phrases[0].Value)
+	assert.Equal(t, []int{0, 1}, phrases[1].Indices)
+	assert.Equal(t, "break out", phrases[1].PhraseStr())
+	assert.Equal(t, 3, phrases[1].Value)
+	assert.Equal(t, []int{0, 2}, phrases[2].Indices)
+	assert.Equal(t, "break out nicely", phrases[2].PhraseStr())
+	assert.Equal(t, 6, phrases[2].Value)
+	assert.Equal(t, s, phrases[2].Sentence)
+
+	// dead end after prefix phrase
+	phrases = trie.FindMembersAt(strings.Split("shooting straight up", " "))
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, "shooting", phrases[0].PhraseStr())
+	assert.Equal(t, 2, phrases[0].Value)
+
+	// longest candidate matches FindMember
+	phrases = trie.FindMembersAt(s)
+	valid, phrase, value := trie.FindMember(s)
+	assert.True(t, valid)
+	assert.Equal(t, phrases[len(phrases)-1].Phrase, phrase)
+	assert.Equal(t, phrases[len(phrases)-1].Value, value)
+
+	// leaf-only mode only finds full phrases
+	trie.SetLeafOnly(true)
+	phrases = trie.FindMembersAt(s)
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, "break out nicely", phrases[0].PhraseStr())
 }
 
 func TestFindAllMember(t *testing.T) {
@@ -324,11 +758,14 @@ func TestFindAllMember(t *testing.T) {
 	phrases := trie.FindAllMembers(sSplit)
 	assert.Equal(t, 0, len(phrases))
 
-	// prefix only
+	// prefix phrase followed by non matching word
 	s = "$AAPL isn't gonna break today"
 	sSplit = strings.Split(s, " ")
 	phrases = trie.FindAllMembers(sSplit)
-	assert.Equal(t, 0, len(phrases))
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, []int{3, 3}, phrases[0].Indices)
+	assert.Equal(t, "break", phrases[0].PhraseStr())
+	assert.Equal(t, 1, phrases[0].Value)
 
 	// one beginning phrase
 	s = "shooting up $AAPL is today!"
@@ -376,13 +813,16 @@ func TestFindAllMember(t *testing.T) {
 	s = "its breaking double bottom $100 $AAPL will break out"
 	sSplit = strings.Split(s, " ")
 	phrases = trie.FindAllMembers(sSplit)
-	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, 3, len(phrases))
 	assert.Equal(t, []int{1, 3}, phrases[0].Indices)
 	assert.Equal(t, "breaking double bottom", phrases[0].PhraseStr())
 	assert.Equal(t, 8, phrases[0].Value)
 	assert.Equal(t, []int{2, 3}, phrases[1].Indices)
 	assert.Equal(t, "double bottom", phrases[1].PhraseStr())
 	assert.Equal(t, 9, phrases[1].Value)
+	assert.Equal(t, []int{7, 8}, phrases[2].Indices)
+	assert.Equal(t, "break out", phrases[2].PhraseStr())
+	assert.Equal(t, 3, phrases[2].Value)
 }
 
 func BenchmarkAdd(b *testing.B) {
@@ -404,6 +844,21 @@ func BenchmarkIsMember(b *testing.B) {
 	}
 }
 
+func BenchmarkIsMemberHighFanout(b *testing.B) {
+	trie := NewPhraseTrie(nil)
+	for i := 0; i < 50000; i++ {
+		trie.Add([]string{fmt.Sprintf("w%d", i), "up"}, i)
+	}
+
+	p := []string{"w49999", "up"}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = trie.IsMember(p)
+	}
+}
+
 func BenchmarkFindAllMembers(b *testing.B) {
 	trie := mockTrieFull()
 	s := "its shooting up it might even break up i bet $100 $AAPL will break out nicely"
@@ -414,3 +869,157 @@ func BenchmarkFindAllMembers(b *testing.B) {
 		_ = trie.FindAllMembers(sSplit)
 	}
 }
+
+func TestWalk(t *testing.T) {
+	trie := mockTrieFull()
+
+	var (
+		phrases []string
+		values  []int
+	)
+
+	trie.Walk(func(phrase []string, value int) bool {
+		phrases = append(phrases, strings.Join(phrase, " "))
+		values = append(values, value)
+		return true
+	})
+
+	assert.Equal(t, []string{
+		"break",
+		"break out",
+		"break out nicely",
+		"break up",
+		"breaking double bottom",
+		"double bottom",
+		"r/g",
+		"shooting",
+		"shooting up",
+	}, phrases)
+	assert.Equal(t, []int{1, 3, 6, 4, 8, 9, 7, 2, 5}, values)
+
+	// phrases are copies
+	var kept [][]string
+	trie.Walk(func(phrase []string, value int) bool {
+		kept = append(kept, phrase)
+		return true
+	})
+	assert.Equal(t, []string{"break"}, kept[0])
+	assert.Equal(t, []string{"break", "out", "nicely"}, kept[2])
+
+	// early stop
+	count := 0
+	trie.Walk(func(phrase []string, value int) bool {
+		count++
+		return count < 3
+	})
+	assert.Equal(t, 3, count)
+
+	// leaf-only
+	trie.SetLeafOnly(true)
+	phrases = nil
+	trie.Walk(func(phrase []string, value int) bool {
+		phrases = append(phrases, strings.Join(phrase, " "))
+		return true
+	})
+	assert.Equal(t, []string{"break out nicely", "break up", "breaking double bottom", "double bottom", "r/g", "shooting up"}, phrases)
+
+	// empty
+	NewPhraseTrie(nil).Walk(func(phrase []string, value int) bool {
+		t.Fatal("walked an empty trie")
+		return true
+	})
+}
+
+func TestAll(t *testing.T) {
+	trie := mockTrieFull()
+
+	found := map[string]int{}
+	for phrase, value := range trie.All() {
+		found[strings.Join(phrase, " ")] = value
+	}
+
+	assert.Equal(t, 9, len(found))
+	assert.Equal(t, 6, found["break out nicely"])
+	assert.Equal(t, 7, found["r/g"])
+
+	var first []string
+	for phrase := range trie.All() {
+		if strings.HasPrefix(phrase[0], "breaking") {
+			first = phrase
+			break
+		}
+	}
+	assert.Equal(t, []string{"breaking", "double", "bottom"}, first)
+
+	// generic values
+	weights := NewPhraseTrieOf(map[string]float64{"big drop": -0.5, "break out": 0.75})
+	for phrase, weight := range weights.All() {
+		assert.Equal(t, []string{"big", "drop"}, phrase)
+		assert.Equal(t, -0.5, weight)
+		break
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	trie, _ := mockLexiconLarge()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		trie.Walk(func(phrase []string, value int) bool {
+			return true
+		})
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	trie := mockTrieFull()
+
+	phrases := func(entries []PhraseEntry[int]) []string {
+		var p []string
+		for _, e := range entries {
+			p = append(p, strings.Join(e.Phrase, " "))
+		}
+		return p
+	}
+
+	entries := trie.WithPrefix([]string{"break"}, 0, nil)
+	assert.Equal(t, []string{"break", "break out", "break out nicely", "break up"}, phrases(entries))
+	assert.Equal(t, 1, entries[0].Value)
+	assert.Equal(t, 6, entries[2].Value)
+
+	entries = trie.WithPrefix([]string{"break", "out"}, 0, nil)
+	assert.Equal(t, []string{"break out", "break out nicely"}, phrases(entries))
+
+	// limit
+	entries = trie.WithPrefix([]string{"break"}, 2, nil)
+	assert.Equal(t, []string{"break", "break out"}, phrases(entries))
+
+	entries = trie.WithPrefix([]string{"break"}, 1, nil)
+	assert.Equal(t, []string{"break"}, phrases(entries))
+
+	// by value
+	desc := func(a, b int) bool { return a > b }
+	entries = trie.WithPrefix([]string{"break"}, 0, desc)
+	assert.Equal(t, []string{"break out nicely", "break up", "break out", "break"}, phrases(entries))
+
+	entries = trie.WithPrefix([]string{"break"}, 2, desc)
+	assert.Equal(t, []string{"break out nicely", "break up"}, phrases(entries))
+
+	// equal values keep lexicographic order
+	same := NewPhraseTrie(map[string]int{"big drop": 1, "big bounce": 1, "big gap": 0})
+	entries = same.WithPrefix([]string{"big"}, 0, func(a, b int) bool { return a < b })
+	assert.Equal(t, []string{"big gap", "big bounce", "big drop"}, phrases(entries))
+
+	// empty prefix is every phrase
+	assert.Equal(t, 9, len(trie.WithPrefix(nil, 0, nil)))
+
+	// prefix not in trie
+	assert.Nil(t, trie.WithPrefix([]string{"break", "down"}, 0, nil))
+	assert.Nil(t, trie.WithPrefix([]string{"breakout"}, 0, nil))
+
+	// leaf-only
+	trie.SetLeafOnly(true)
+	entries = trie.WithPrefix([]string{"break"}, 0, nil)
+	assert.Equal(t, []string{"break out nicely", "break up"}, phrases(entries))
+}
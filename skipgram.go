@@ -0,0 +1,93 @@
+package trie
+
+/* GAP TOLERANT (SKIP-GRAM) MATCHING */
+
+// FindMemberSkip is FindMember allowing up to maxSkip words of the sequence
+// to be skipped between the words of a phrase, so "break out" is found in
+// "break right out" with a maxSkip of 1. The sequence must begin with the
+// first word of the phrase
+//
+// Returns a PhraseContext of the longest phrase, i.e. the one ending
+// furthest into the sequence, with the positions of the matched words
+// in Positions, or nil if the sequence does not begin with a member phrase
+func (n *PhraseTrie[V]) FindMemberSkip(sequence []string, maxSkip int) *PhraseContextOf[V] {
+	words := n.normalizeSentence(sequence)
+
+	end, positions, pattern := n.longestSkip(words, maxSkip)
+	if end == nil {
+		return nil
+	}
+
+	return n.restore(newMatchContext(words, 0, end.value, positions, pattern), sequence)
+}
+
+// FindAllMembersSkip is FindAllMembers allowing up to maxSkip words of the
+// sentence to be skipped between the words of a phrase, see FindMemberSkip
+// Positions of the returned contexts are the positions of the matched words
+// in the sentence, Indices the first and last of them
+func (n *PhraseTrie[V]) FindAllMembersSkip(sentence []string, maxSkip int) PCtxListOf[V] {
+	foundMembers := make(PCtxListOf[V], 0)
+	words := n.normalizeSentence(sentence)
+
+	for i := 0; i < len(words); i++ {
+		if n.IsLeaf() { // no children to match
+			return nil
+		}
+
+		end, positions, pattern := n.longestSkip(words[i:], maxSkip)
+		if end != nil { // valid phrase was found
+			pc := newMatchContext(words, i, end.value, positions, pattern)
+			foundMembers = append(foundMembers, n.restore(pc, sentence))
+		}
+	}
+
+	return foundMembers
+}
+
+// longestSkip returns the end node, matched word positions and stored phrase
+// with wildcards of the member phrase the sequence begins with that ends
+// furthest into it, preferring the one skipping the fewest words on ties
+// The end node is nil if there is none, the stored phrase is nil on exact matches
+func (n *PhraseTrie[V]) longestSkip(sequence []string, maxSkip int) (*PhraseTrie[V], []int, []string) {
+	var (
+		end       *PhraseTrie[V]
+		positions []int
+		pattern   []string
+	)
+
+	if maxSkip < 0 {
+		maxSkip = 0
+	}
+
+	n.search(sequence, maxSkip, func(node *PhraseTrie[V], p []int, pat []string) {
+		if end == nil || p[len(p)-1] > positions[len(positions)-1] || // keep longest phrase so far
+			p[len(p)-1] == positions[len(positions)-1] && len(p) > len(positions) { // with fewest skips
+			end = node
+			positions = append(positions[:0], p...)
+			pattern = append(pattern[:0], pat...)
+		}
+	})
+
+	if len(pattern) == 0 {
+		pattern = nil
+	}
+
+	return end, positions, pattern
+}
+
+// newMatchContext creates the PhraseContext of a phrase matched in the
+// sentence at the given positions, offset from the start of the sentence,
+// setting Pattern to the stored phrase if it is not nil
+func newMatchContext[V any](sentence []string, offset int, value V, positions []int, pattern []string) *PhraseContextOf[V] {
+	phrase := make([]string, len(positions))
+	for i := range positions {
+		positions[i] += offset
+		phrase[i] = sentence[positions[i]]
+	}
+
+	pc := NewPhraseContext(phrase, sentence, []int{positions[0], positions[len(positions)-1]}, value)
+	pc.Positions = positions
+	pc.Pattern = pattern
+
+	return pc
+}
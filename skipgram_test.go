@@ -0,0 +1,114 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMemberSkip(t *testing.T) {
+	trie := mockTrieFull()
+
+	pc := trie.FindMemberSkip(strings.Split("break right out", " "), 1)
+	assert.Equal(t, []string{"break", "out"}, pc.Phrase)
+	assert.Equal(t, []int{0, 2}, pc.Positions)
+	assert.Equal(t, []int{0, 2}, pc.Indices)
+	assert.Equal(t, 3, pc.Value)
+	assert.Nil(t, pc.Pattern)
+
+	pc = trie.FindMemberSkip(strings.Split("shooting straight up", " "), 1)
+	assert.Equal(t, []string{"shooting", "up"}, pc.Phrase)
+	assert.Equal(t, []int{0, 2}, pc.Positions)
+	assert.Equal(t, 5, pc.Value)
+
+	pc = trie.FindMemberSkip(strings.Split("break right out really nicely", " "), 1)
+	assert.Equal(t, []string{"break", "out", "nicely"}, pc.Phrase)
+	assert.Equal(t, []int{0, 2, 4}, pc.Positions)
+	assert.Equal(t, []int{0, 4}, pc.Indices)
+	assert.Equal(t, 6, pc.Value)
+
+	// no skips
+	pc = trie.FindMemberSkip(strings.Split("break right out", " "), 0)
+	assert.Equal(t, []string{"break"}, pc.Phrase)
+	assert.Equal(t, []int{0}, pc.Positions)
+
+	pc = trie.FindMemberSkip(strings.Split("break right out", " "), -1)
+	assert.Equal(t, []string{"break"}, pc.Phrase)
+
+	pc = trie.FindMemberSkip(strings.Split("break out nicely", " "), 0)
+	assert.Equal(t, []int{0, 1, 2}, pc.Positions)
+
+	// too many skipped words
+	pc = trie.FindMemberSkip(strings.Split("break right now out", " "), 1)
+	assert.Equal(t, []string{"break"}, pc.Phrase)
+
+	pc = trie.FindMemberSkip(strings.Split("break right now out", " "), 2)
+	assert.Equal(t, []string{"break", "out"}, pc.Phrase)
+	assert.Equal(t, []int{0, 3}, pc.Positions)
+
+	// must begin with the phrase
+	assert.Nil(t, trie.FindMemberSkip(strings.Split("right break out", " "), 2))
+	assert.Nil(t, trie.FindMemberSkip(nil, 2))
+}
+
+func TestFindAllMembersSkip(t *testing.T) {
+	trie := mockTrieFull()
+	sentence := strings.Split("its shooting straight up and will break right out", " ")
+
+	phrases := trie.FindAllMembersSkip(sentence, 1)
+	assert.Equal(t, 2, len(phrases))
+
+	assert.Equal(t, []string{"shooting", "up"}, phrases[0].Phrase)
+	assert.Equal(t, []int{1, 3}, phrases[0].Positions)
+	assert.Equal(t, []int{1, 3}, phrases[0].Indices)
+	assert.Equal(t, sentence, phrases[0].Sentence)
+
+	assert.Equal(t, []string{"break", "out"}, phrases[1].Phrase)
+	assert.Equal(t, []int{6, 8}, phrases[1].Positions)
+	assert.Equal(t, 3, phrases[1].Value)
+
+	// same as FindAllMembers without skips
+	sentence = strings.Split("its shooting up it might even break up i bet $100 $AAPL will break out nicely", " ")
+	exact := trie.FindAllMembers(sentence)
+	phrases = trie.FindAllMembersSkip(sentence, 0)
+	assert.Equal(t, len(exact), len(phrases))
+	for i := range exact {
+		assert.Equal(t, exact[i].Phrase, phrases[i].Phrase)
+		assert.Equal(t, exact[i].Indices, phrases[i].Indices)
+		assert.Equal(t, exact[i].Value, phrases[i].Value)
+	}
+
+	assert.Nil(t, NewPhraseTrie(nil).FindAllMembersSkip(sentence, 1))
+}
+
+func TestFindAllMembersSkipWildcards(t *testing.T) {
+	trie := mockTrieWildcards()
+	sentence := strings.Split("it will break the key resistance", " ")
+
+	phrases := trie.FindAllMembersSkip(sentence, 1)
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, []string{"break", "the", "resistance"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"break", "*", "resistance"}, phrases[0].Pattern)
+	assert.Equal(t, []int{2, 3, 5}, phrases[0].Positions)
+	assert.Equal(t, []int{2, 5}, phrases[0].Indices)
+	assert.Equal(t, 4, phrases[0].Value)
+
+	// a wider ** match is preferred over skipping words
+	pc := trie.FindMemberSkip(strings.Split("short it hard today", " "), 1)
+	assert.Equal(t, []string{"short", "it", "hard", "today"}, pc.Phrase)
+	assert.Equal(t, []string{"short", "**", "today"}, pc.Pattern)
+	assert.Equal(t, []int{0, 1, 2, 3}, pc.Positions)
+	assert.Equal(t, -6, pc.Value)
+}
+
+func BenchmarkFindAllMembersSkip(b *testing.B) {
+	trie := mockTrieFull()
+	sSplit := strings.Split("its shooting straight up it might even break right up i bet $100 $AAPL will break right out really nicely", " ")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.FindAllMembersSkip(sSplit, 2)
+	}
+}
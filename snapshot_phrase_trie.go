@@ -0,0 +1,241 @@
+package trie
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/* ATOMIC SNAPSHOT IMPLEMENTATION */
+
+// A SnapshotPhraseTrie holds the current snapshot of a PhraseTrie that is
+// replaced as a whole instead of modified in place, e.g. a lexicon that is
+// reloaded intraday
+//
+// New snapshots are published with an atomic pointer swap, so lookups never
+// wait on a rebuild and calls already in flight finish on the old snapshot.
+// A published snapshot must not be modified. A SnapshotPhraseTrie is safe
+// for concurrent use
+type SnapshotPhraseTrie[V any] struct {
+	current atomic.Pointer[PhraseTrie[V]]
+
+	mu        sync.Mutex // serializes publishing
+	started   uint64     // generation of the last started build
+	published uint64     // generation of the current snapshot
+}
+
+// NewSnapshotPhraseTrie creates a SnapshotPhraseTrie holding the given Trie,
+// or a new empty Trie if nil
+func NewSnapshotPhraseTrie[V any](trie *PhraseTrie[V]) *SnapshotPhraseTrie[V] {
+	s := &SnapshotPhraseTrie[V]{}
+	s.Store(trie)
+
+	return s
+}
+
+// Load returns the current snapshot
+// Use it to run several lookups against the same snapshot
+func (s *SnapshotPhraseTrie[V]) Load() *PhraseTrie[V] {
+	return s.current.Load()
+}
+
+// Store publishes the given Trie, or a new empty Trie if nil, as the current snapshot
+func (s *SnapshotPhraseTrie[V]) Store(trie *PhraseTrie[V]) {
+	s.publish(s.begin(), trie)
+}
+
+// Rebuild calls build in a new goroutine and publishes the Trie it returns
+// The returned channel receives the build error, if any, and is then closed
+// If a build started later has already been published the result is discarded
+func (s *SnapshotPhraseTrie[V]) Rebuild(build func() (*PhraseTrie[V], error)) <-chan error {
+	gen := s.begin()
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+
+		trie, err := build()
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		s.publish(gen, trie)
+	}()
+
+	return errc
+}
+
+// begin returns the generation of a new build
+func (s *SnapshotPhraseTrie[V]) begin() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.started++
+
+	return s.started
+}
+
+// publish swaps in the Trie built by generation gen,
+// unless a later generation is already published
+func (s *SnapshotPhraseTrie[V]) publish(gen uint64, trie *PhraseTrie[V]) bool {
+	if trie == nil {
+		trie = NewPhraseTrieOf[V](nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gen < s.published {
+		return false
+	}
+
+	s.published = gen
+	s.current.Store(trie)
+
+	return true
+}
+
+// IsMember checks if the given phrase is a member of the current snapshot, see PhraseTrie.IsMember
+func (s *SnapshotPhraseTrie[V]) IsMember(phrase []string) (bool, V) {
+	return s.Load().IsMember(phrase)
+}
+
+// FindMember finds the longest member phrase the sequence begins with
+// in the current snapshot, see PhraseTrie.FindMember
+func (s *SnapshotPhraseTrie[V]) FindMember(sequence []string) (bool, []string, V) {
+	return s.Load().FindMember(sequence)
+}
+
+// FindMembersAt finds all member phrases the sequence begins with
+// in the current snapshot, see PhraseTrie.FindMembersAt
+func (s *SnapshotPhraseTrie[V]) FindMembersAt(sequence []string) PCtxListOf[V] {
+	return s.Load().FindMembersAt(sequence)
+}
+
+// FindAllMembers finds all member phrases in the sentence
+// in the current snapshot, see PhraseTrie.FindAllMembers
+func (s *SnapshotPhraseTrie[V]) FindAllMembers(sentence []string) PCtxListOf[V] {
+	return s.Load().FindAllMembers(sentence)
+}
+
+// A LexiconWatcher polls a lexicon file and publishes a new snapshot
+// loaded from it whenever the file's modification time changes
+//
+// Lexicon files should be replaced by renaming a new file over the old
+// one. If a load fails, e.g. on a partially written file, the current
+// snapshot is kept and the load is retried on every poll until it succeeds
+//
+// Loaded tries take the settings of the current snapshot: its Normalizer,
+// Tokenizer, membership mode and wildcard cap. Set them on the Trie the
+// SnapshotPhraseTrie is created with
+type LexiconWatcher[V any] struct {
+	snapshot *SnapshotPhraseTrie[V]
+	path     string
+	load     func(*os.File) (*PhraseTrie[V], error)
+	modTime  time.Time
+
+	mu  sync.Mutex
+	err error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchLexicon loads a lexicon file in the given format into the snapshot,
+// like LoadLexicon, and then polls the file every interval until stopped
+// Phrases are split into words with the Tokenizer of the current snapshot,
+// see SetTokenizer, whose settings every reloaded Trie keeps
+func WatchLexicon(s *SnapshotPhraseTrie[int], path string, format LexiconFormat, interval time.Duration) (*LexiconWatcher[int], error) {
+	return WatchLexiconOf(s, path, format, strconv.Atoi, interval)
+}
+
+// WatchLexiconOf is the generic form of WatchLexicon for any phrase value type,
+// values are parsed like LoadLexiconOf
+func WatchLexiconOf[V any](s *SnapshotPhraseTrie[V], path string, format LexiconFormat, parse func(string) (V, error), interval time.Duration) (*LexiconWatcher[V], error) {
+	w := &LexiconWatcher[V]{
+		snapshot: s,
+		path:     path,
+		load: func(file *os.File) (*PhraseTrie[V], error) {
+			return LoadLexiconWith(file, format, parse, s.Load().config().tokenizer)
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.poll(interval)
+
+	return w, nil
+}
+
+// Err returns the error of the last poll, nil if it succeeded
+func (w *LexiconWatcher[V]) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// Stop stops polling and waits for a reload in progress to finish
+// Stop must be called only once
+func (w *LexiconWatcher[V]) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *LexiconWatcher[V]) poll(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			err := w.reload()
+
+			w.mu.Lock()
+			w.err = err
+			w.mu.Unlock()
+		}
+	}
+}
+
+// reload loads the file and publishes it if its modification time changed
+func (w *LexiconWatcher[V]) reload() error {
+	file, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(w.modTime) { // unchanged
+		return nil
+	}
+
+	gen := w.snapshot.begin()
+
+	trie, err := w.load(file)
+	if err != nil {
+		return err
+	}
+
+	trie.copyConfig(w.snapshot.Load())
+	w.snapshot.publish(gen, trie)
+	w.modTime = info.ModTime()
+
+	return nil
+}
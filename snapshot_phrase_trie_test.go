@@ -0,0 +1,216 @@
+package trie
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotPhraseTrie(t *testing.T) {
+	s := NewSnapshotPhraseTrie(mockTrieFull())
+
+	member, value := s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	valid, phrase, _ := s.FindMember([]string{"break", "out", "nicely"})
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrase)
+	assert.Equal(t, 3, len(s.FindMembersAt([]string{"break", "out", "nicely"})))
+	assert.Equal(t, 2, len(s.FindAllMembers(strings.Split("$AAPL will break out then break up", " "))))
+
+	old := s.Load()
+	s.Store(NewPhraseTrie(map[string]int{"big drop": -5}))
+
+	member, _ = s.IsMember([]string{"break", "out"})
+	assert.False(t, member)
+	member, value = s.IsMember([]string{"big", "drop"})
+	assert.True(t, member)
+	assert.Equal(t, -5, value)
+
+	// old snapshot is untouched
+	member, _ = old.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+
+	// nil
+	s.Store(nil)
+	assert.NotNil(t, s.Load())
+	assert.Nil(t, s.FindAllMembers([]string{"big", "drop"}))
+
+	empty := NewSnapshotPhraseTrie[float64](nil)
+	member, _ = empty.IsMember([]string{"big", "drop"})
+	assert.False(t, member)
+}
+
+func TestSnapshotRebuild(t *testing.T) {
+	s := NewSnapshotPhraseTrie[int](nil)
+
+	err := <-s.Rebuild(func() (*PhraseTrieNode, error) {
+		return mockTrieFull(), nil
+	})
+	assert.Nil(t, err)
+
+	member, _ := s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+
+	// failed build keeps the current snapshot
+	buildErr := errors.New("bad lexicon")
+	err = <-s.Rebuild(func() (*PhraseTrieNode, error) {
+		return nil, buildErr
+	})
+	assert.Equal(t, buildErr, err)
+
+	member, _ = s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+
+	// a slow older build does not replace a newer one
+	release := make(chan struct{})
+	slow := s.Rebuild(func() (*PhraseTrieNode, error) {
+		<-release
+		return NewPhraseTrie(map[string]int{"old": 1}), nil
+	})
+	fast := s.Rebuild(func() (*PhraseTrieNode, error) {
+		return NewPhraseTrie(map[string]int{"new": 2}), nil
+	})
+
+	assert.Nil(t, <-fast)
+	close(release)
+	assert.Nil(t, <-slow)
+
+	member, _ = s.IsMember([]string{"new"})
+	assert.True(t, member)
+	member, _ = s.IsMember([]string{"old"})
+	assert.False(t, member)
+}
+
+// TestSnapshotConcurrent is run with the race detector to prove lookups
+// can run while new snapshots are published
+func TestSnapshotConcurrent(t *testing.T) {
+	s := NewSnapshotPhraseTrie(mockTrieFull())
+	sentence := strings.Split("its shooting up it might even break up i bet $100 $AAPL will break out nicely", " ")
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				// every snapshot holds the same phrases
+				assert.Equal(t, 3, len(s.FindAllMembers(sentence)))
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		<-s.Rebuild(func() (*PhraseTrieNode, error) {
+			return mockTrieFull(), nil
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestWatchLexicon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexicon.tsv")
+	modTime := time.Now().Add(-time.Hour)
+
+	write := func(lexicon string) {
+		tmp := path + ".tmp"
+		assert.Nil(t, os.WriteFile(tmp, []byte(lexicon), 0o644))
+
+		modTime = modTime.Add(time.Second)
+		assert.Nil(t, os.Chtimes(tmp, modTime, modTime))
+		assert.Nil(t, os.Rename(tmp, path))
+	}
+
+	write("break out\t3\n")
+
+	s := NewSnapshotPhraseTrie[int](nil)
+	w, err := WatchLexicon(s, path, FormatTSV, 5*time.Millisecond)
+	assert.Nil(t, err)
+	defer w.Stop()
+
+	// loaded right away
+	member, value := s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	write("break out\t3\nbig drop\t-5\n")
+	assert.True(t, waitForMember(s, []string{"big", "drop"}))
+	assert.Nil(t, w.Err())
+
+	// bad file keeps the current snapshot
+	write("big drop\tnot a number\n")
+	assert.True(t, waitForErr(w))
+
+	member, _ = s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+
+	// fixed file
+	write("short squeeze\t6\n")
+	assert.True(t, waitForMember(s, []string{"short", "squeeze"}))
+	assert.Nil(t, w.Err())
+
+	member, _ = s.IsMember([]string{"break", "out"})
+	assert.False(t, member)
+
+	// missing file
+	_, err = WatchLexicon(s, filepath.Join(t.TempDir(), "missing.tsv"), FormatTSV, time.Second)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestWatchLexiconNormalizer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexicon.tsv")
+	assert.Nil(t, os.WriteFile(path, []byte("Break Out\t3\n"), 0o644))
+
+	trie := NewPhraseTrie(nil)
+	trie.SetNormalizer(Lowercase)
+	trie.SetLeafOnly(true)
+
+	s := NewSnapshotPhraseTrie(trie)
+	w, err := WatchLexicon(s, path, FormatTSV, time.Hour)
+	assert.Nil(t, err)
+	defer w.Stop()
+
+	// loaded tries take the settings of the current snapshot
+	assert.NotSame(t, trie, s.Load())
+	assert.True(t, s.Load().config().leafOnly)
+
+	member, value := s.IsMember([]string{"BREAK", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+}
+
+// waitForMember polls the snapshot until the phrase is a member
+func waitForMember(s *SnapshotPhraseTrie[int], phrase []string) bool {
+	for i := 0; i < 500; i++ {
+		if member, _ := s.IsMember(phrase); member {
+			return true
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
+
+// waitForErr polls the watcher until a reload fails
+func waitForErr(w *LexiconWatcher[int]) bool {
+	for i := 0; i < 500; i++ {
+		if w.Err() != nil {
+			return true
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package trie
+
+import (
+	"unsafe"
+)
+
+// Stats describes the shape and estimated memory use of a Trie
+// The depth of a node is its distance from the root, so the depth
+// of a member phrase is its number of words
+type Stats struct {
+	Nodes    int     // number of nodes, including the root
+	Phrases  int     // number of member phrases
+	MaxDepth int     // depth of the longest member phrase
+	AvgDepth float64 // average depth of the member phrases
+
+	// Fanout is the fanout distribution per level, Fanout[d][k] is the
+	// number of nodes at depth d with k children
+	Fanout []map[int]int
+
+	// Bytes is the estimated heap size of the Trie nodes, their keys and
+	// child lists, not counting memory referenced by the values
+	Bytes int64
+}
+
+// record adds a node at the given depth with the given number of children
+func (s *Stats) record(depth, children int) {
+	s.Nodes++
+
+	for len(s.Fanout) <= depth {
+		s.Fanout = append(s.Fanout, map[int]int{})
+	}
+	s.Fanout[depth][children]++
+}
+
+// Stats walks this Trie and returns its Stats
+// Membership follows this Trie's mode, see SetLeafOnly
+func (n *PhraseTrie[V]) Stats() Stats {
+	var (
+		s     Stats
+		total int
+	)
+
+	leafOnly := n.config().leafOnly
+
+	var visit func(node *PhraseTrie[V], depth int)
+	visit = func(node *PhraseTrie[V], depth int) {
+		s.record(depth, len(node.children))
+		s.Bytes += node.heapSize()
+
+		if depth != 0 && node.endsPhrase(leafOnly) {
+			s.Phrases++
+			total += depth
+
+			if depth > s.MaxDepth {
+				s.MaxDepth = depth
+			}
+		}
+
+		for _, child := range node.children {
+			visit(child, depth+1)
+		}
+	}
+
+	visit(n, 0)
+
+	if s.Phrases != 0 {
+		s.AvgDepth = float64(total) / float64(s.Phrases)
+	}
+
+	return s
+}
+
+// heapSize estimates the bytes allocated for this node, its key,
+// its child list, its child index and its root settings
+func (n *PhraseTrie[V]) heapSize() int64 {
+	size := int64(unsafe.Sizeof(*n)) + int64(len(n.key))
+	size += int64(cap(n.children)) * int64(unsafe.Sizeof(n))
+
+	if n.ext == nil {
+		return size
+	}
+
+	size += int64(unsafe.Sizeof(*n.ext))
+
+	if n.ext.index != nil { // key, value and a control byte per slot, at 7/8 load
+		slot := int64(unsafe.Sizeof(n.key)+unsafe.Sizeof(n)) + 1
+		size += int64(len(n.ext.index)) * slot * 8 / 7
+	}
+
+	if n.ext.root != nil {
+		size += int64(unsafe.Sizeof(*n.ext.root))
+	}
+
+	return size
+}
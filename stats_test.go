@@ -0,0 +1,59 @@
+package trie
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	trie := mockTrieFull()
+
+	s := trie.Stats()
+	assert.Equal(t, 13, s.Nodes)
+	assert.Equal(t, 9, s.Phrases)
+	assert.Equal(t, 3, s.MaxDepth)
+	assert.InDelta(t, 17.0/9, s.AvgDepth, 1e-9)
+	assert.Equal(t, []map[int]int{
+		{5: 1},
+		{0: 1, 1: 3, 2: 1},
+		{0: 3, 1: 2},
+		{0: 2},
+	}, s.Fanout)
+
+	// at least the nodes and keys
+	assert.True(t, s.Bytes > int64(s.Nodes)*int64(unsafe.Sizeof(*trie)))
+
+	// leaf-only
+	trie.SetLeafOnly(true)
+	s = trie.Stats()
+	assert.Equal(t, 13, s.Nodes)
+	assert.Equal(t, 6, s.Phrases)
+	assert.InDelta(t, 13.0/6, s.AvgDepth, 1e-9)
+
+	// empty
+	s = NewPhraseTrie(nil).Stats()
+	assert.Equal(t, 1, s.Nodes)
+	assert.Equal(t, 0, s.Phrases)
+	assert.Equal(t, 0, s.MaxDepth)
+	assert.Equal(t, 0.0, s.AvgDepth)
+	assert.Equal(t, []map[int]int{{0: 1}}, s.Fanout)
+
+	// child index
+	large, _ := mockLexiconLarge()
+	s = large.Stats()
+	assert.Equal(t, 50, len(large.children))
+	assert.NotNil(t, large.childIndex())
+	assert.True(t, s.Bytes > int64(s.Nodes)*int64(unsafe.Sizeof(*large))+int64(len(large.childIndex()))*24)
+}
+
+func BenchmarkStats(b *testing.B) {
+	trie, _ := mockLexiconLarge()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.Stats()
+	}
+}
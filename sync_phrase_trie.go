@@ -0,0 +1,97 @@
+package trie
+
+import (
+	"sync"
+)
+
+// A SyncPhraseTrie is a PhraseTrie that is safe for concurrent use
+// Any number of goroutines can look up phrases at the same time,
+// while adding and removing phrases is serialized with all other calls
+type SyncPhraseTrie[V any] struct {
+	mu   sync.RWMutex
+	trie *PhraseTrie[V]
+}
+
+// NewSyncPhraseTrie wraps the given Trie, or a new empty Trie if nil, for concurrent use
+// The Trie must not be used directly afterwards
+func NewSyncPhraseTrie[V any](trie *PhraseTrie[V]) *SyncPhraseTrie[V] {
+	if trie == nil {
+		trie = NewPhraseTrieOf[V](nil)
+	}
+
+	return &SyncPhraseTrie[V]{trie: trie}
+}
+
+// Add adds a phrase key/value to this Trie, see PhraseTrie.Add
+func (s *SyncPhraseTrie[V]) Add(phrase []string, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trie.Add(phrase, value)
+}
+
+// Set adds or overwrites a phrase key/value in this Trie, see PhraseTrie.Set
+func (s *SyncPhraseTrie[V]) Set(phrase []string, value V) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.trie.Set(phrase, value)
+}
+
+// Update sets the value of a phrase to the result of fn, see PhraseTrie.Update
+// fn is called with the write lock held and must not use this Trie
+func (s *SyncPhraseTrie[V]) Update(phrase []string, fn func(old V, ok bool) V) V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.trie.Update(phrase, fn)
+}
+
+// Remove removes a phrase from this Trie, see PhraseTrie.Remove
+func (s *SyncPhraseTrie[V]) Remove(phrase []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.trie.Remove(phrase)
+}
+
+// IsMember checks if the given phrase is a member of this Trie, see PhraseTrie.IsMember
+func (s *SyncPhraseTrie[V]) IsMember(phrase []string) (bool, V) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trie.IsMember(phrase)
+}
+
+// FindMember finds the longest member phrase the sequence begins with, see PhraseTrie.FindMember
+func (s *SyncPhraseTrie[V]) FindMember(sequence []string) (bool, []string, V) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trie.FindMember(sequence)
+}
+
+// FindMembersAt finds all member phrases the sequence begins with, see PhraseTrie.FindMembersAt
+func (s *SyncPhraseTrie[V]) FindMembersAt(sequence []string) PCtxListOf[V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trie.FindMembersAt(sequence)
+}
+
+// FindAllMembers finds all member phrases in the sentence, see PhraseTrie.FindAllMembers
+func (s *SyncPhraseTrie[V]) FindAllMembers(sentence []string) PCtxListOf[V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trie.FindAllMembers(sentence)
+}
+
+// View calls fn with the wrapped Trie while holding the read lock, e.g. to
+// Compile, Freeze or WriteTo it. fn must not modify the Trie or keep it
+func (s *SyncPhraseTrie[V]) View(fn func(trie *PhraseTrie[V])) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn(s.trie)
+}
@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncPhraseTrie(t *testing.T) {
+	s := NewSyncPhraseTrie(mockTrieFull())
+
+	member, value := s.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 3, value)
+
+	s.Add([]string{"big", "drop"}, -4)
+	prev, existed := s.Set([]string{"big", "drop"}, -5)
+	assert.True(t, existed)
+	assert.Equal(t, -4, prev)
+	assert.Equal(t, -6, s.Update([]string{"big", "drop"}, func(old int, ok bool) int { return old - 1 }))
+
+	valid, phrase, value := s.FindMember([]string{"big", "drop", "today"})
+	assert.True(t, valid)
+	assert.Equal(t, []string{"big", "drop"}, phrase)
+	assert.Equal(t, -6, value)
+
+	assert.Equal(t, 3, len(s.FindMembersAt(strings.Split("break out nicely", " "))))
+	assert.Equal(t, 2, len(s.FindAllMembers(strings.Split("$AAPL big drop then break up", " "))))
+
+	assert.True(t, s.Remove([]string{"big", "drop"}))
+	assert.False(t, s.Remove([]string{"big", "drop"}))
+
+	var frozen *FrozenPhraseTrie[int]
+	s.View(func(trie *PhraseTrie[int]) {
+		frozen = trie.Freeze()
+	})
+	assert.Equal(t, 9, frozen.Len())
+
+	// empty
+	empty := NewSyncPhraseTrie[float64](nil)
+	empty.Add([]string{"break", "out"}, 0.75)
+
+	member, weight := empty.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	assert.Equal(t, 0.75, weight)
+}
+
+// TestSyncPhraseTrieConcurrent is run with the race detector to
+// prove readers and writers can share a SyncPhraseTrie
+func TestSyncPhraseTrieConcurrent(t *testing.T) {
+	s := NewSyncPhraseTrie(mockTrieFull())
+	sentence := strings.Split("its shooting up it might even break up i bet $100 $AAPL will break out nicely", " ")
+
+	var wg sync.WaitGroup
+
+	// writers
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				phrase := []string{fmt.Sprintf("$T%d", w), fmt.Sprintf("up%d", i)}
+				s.Add(phrase, i)
+				s.Update([]string{"break", "up"}, func(old int, ok bool) int { return old })
+
+				if i%2 == 0 {
+					s.Remove(phrase)
+				}
+			}
+		}(w)
+	}
+
+	// readers
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				phrases := s.FindAllMembers(sentence)
+				assert.Equal(t, 3, len(phrases))
+
+				member, value := s.IsMember([]string{"break", "out", "nicely"})
+				assert.True(t, member)
+				assert.Equal(t, 6, value)
+
+				valid, _, _ := s.FindMember([]string{"shooting", "up"})
+				assert.True(t, valid)
+
+				s.FindMembersAt([]string{"$T0", "up1"})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// only odd phrases remain
+	for w := 0; w < 4; w++ {
+		for i := 0; i < 200; i++ {
+			member, _ := s.IsMember([]string{fmt.Sprintf("$T%d", w), fmt.Sprintf("up%d", i)})
+			assert.Equal(t, i%2 == 1, member)
+		}
+	}
+}
+
+func BenchmarkSyncFindAllMembersParallel(b *testing.B) {
+	s := NewSyncPhraseTrie(mockTrieFull())
+	sSplit := strings.Split("its shooting up it might even break up i bet $100 $AAPL will break out nicely", " ")
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = s.FindAllMembers(sSplit)
+		}
+	})
+}
@@ -0,0 +1,331 @@
+package trie
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/* TEXT TOKENIZATION */
+
+// A Tokenizer splits text into the words phrases are matched against
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// A SpanTokenizer is a Tokenizer that also returns where each token is in
+// the text, so FindAllMembersText can give the offsets of found phrases
+type SpanTokenizer interface {
+	Tokenizer
+
+	// TokenizeSpans returns the tokens of the text and the [start, end)
+	// byte offsets of each token in it
+	TokenizeSpans(text string) ([]string, [][2]int)
+}
+
+// TokenizerFunc adapts an ordinary function to a Tokenizer
+type TokenizerFunc func(text string) []string
+
+// Tokenize calls f(text)
+func (f TokenizerFunc) Tokenize(text string) []string {
+	return f(text)
+}
+
+var (
+	// WhitespaceTokenizer splits text on runs of whitespace only, like strings.Fields
+	WhitespaceTokenizer Tokenizer = whitespaceTokenizer{}
+)
+
+// defaultTokenizer is the Tokenizer used for NewPhraseTrie keys, lexicon
+// phrases and FindAllMembersText unless another one is given
+var defaultTokenizer Tokenizer = SocialTokenizer{}
+
+// whitespaceTokenizer is the SpanTokenizer of WhitespaceTokenizer
+type whitespaceTokenizer struct{}
+
+func (whitespaceTokenizer) Tokenize(text string) []string {
+	return strings.Fields(text)
+}
+
+func (whitespaceTokenizer) TokenizeSpans(text string) ([]string, [][2]int) {
+	var (
+		tokens []string
+		spans  [][2]int
+	)
+
+	start := -1
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r) && start != -1:
+			tokens = append(tokens, text[start:i])
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start == -1:
+			start = i
+		}
+	}
+
+	if start != -1 {
+		tokens = append(tokens, text[start:])
+		spans = append(spans, [2]int{start, len(text)})
+	}
+
+	return tokens, spans
+}
+
+// A SocialTokenizer splits social media text into words and keeps
+// together the tokens that are common in it:
+//
+//	words      with inner ' - / . & _ like "don't", "short-term" and "r/g"
+//	numbers    with inner , and . like "1,000" and "1.5"
+//	cashtags   like "$AAPL" and "$BRK.B", and amounts like "$100"
+//	mentions   like "@trader_joe"
+//	hashtags   like "#bullish"
+//	URLs       starting with http://, https:// or www.
+//	emoji      one token per emoji, including skin tones, flags and ZWJ sequences
+//
+// Any other punctuation or symbol is a token of its own, repeats like "!!!"
+// and "..." are kept together, and whitespace separates tokens
+type SocialTokenizer struct{}
+
+// Tokenize splits the text into tokens
+func (SocialTokenizer) Tokenize(text string) []string {
+	var tokens []string
+
+	scanTokens(text, func(start, end int) {
+		tokens = append(tokens, text[start:end])
+	})
+
+	return tokens
+}
+
+// TokenizeSpans splits the text into tokens and returns their byte offsets
+func (SocialTokenizer) TokenizeSpans(text string) ([]string, [][2]int) {
+	var (
+		tokens []string
+		spans  [][2]int
+	)
+
+	scanTokens(text, func(start, end int) {
+		tokens = append(tokens, text[start:end])
+		spans = append(spans, [2]int{start, end})
+	})
+
+	return tokens, spans
+}
+
+// scanTokens calls fn with the byte offsets of each SocialTokenizer token of the text
+func scanTokens(text string, fn func(start, end int)) {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		end := i + tokenLen(text[i:])
+		fn(i, end)
+		i = end
+	}
+}
+
+// tokenLen returns the length in bytes of the token s begins with
+func tokenLen(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+
+	switch {
+	case isURL(s):
+		return urlLen(s)
+	case r == '$' && isWordRune(next(s[size:])):
+		return size + wordLen(s[size:], "._")
+	case (r == '@' || r == '#') && isWordRune(next(s[size:])):
+		return size + wordLen(s[size:], "_")
+	case isEmoji(r):
+		return emojiLen(s)
+	case isWordRune(r):
+		return wordLen(s, "'’-/.&_")
+	}
+
+	// punctuation or symbol, with its repeats
+	l := size
+	for strings.HasPrefix(s[l:], s[:size]) {
+		l += size
+	}
+
+	return l
+}
+
+// next returns the first rune of s, or utf8.RuneError if it is empty
+func next(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// wordLen returns the length of the word s begins with, including any of
+// the connectors between word runes, and commas between digits
+func wordLen(s string, connectors string) int {
+	l := 0
+	for l < len(s) {
+		r, size := utf8.DecodeRuneInString(s[l:])
+		if isWordRune(r) {
+			l += size
+			continue
+		}
+
+		after := next(s[l+size:])
+		if strings.ContainsRune(connectors, r) && isWordRune(after) {
+			l += size
+			continue
+		}
+
+		if r == ',' && unicode.IsDigit(after) && l > 0 && unicode.IsDigit(lastRune(s[:l])) {
+			l += size
+			continue
+		}
+
+		break
+	}
+
+	return l
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func isURL(s string) bool {
+	for _, prefix := range []string{"http://", "https://", "www."} {
+		if len(s) > len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// urlLen returns the length of the URL s begins with, up to the next
+// whitespace and without trailing punctuation
+func urlLen(s string) int {
+	l := strings.IndexFunc(s, unicode.IsSpace)
+	if l == -1 {
+		l = len(s)
+	}
+
+	return len(strings.TrimRight(s[:l], ".,;:!?)]}\"'"))
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || // pictographs, emoticons, flags
+		(r >= 0x2600 && r <= 0x27BF) || // misc symbols and dingbats
+		(r >= 0x2B00 && r <= 0x2BFF) || // stars, arrows
+		r == 0x2122 || r == 0x2139 || r == 0x3030 || r == 0x303D
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// emojiLen returns the length of the emoji s begins with, including its
+// modifiers, a second regional indicator for flags and ZWJ sequences
+func emojiLen(s string) int {
+	r, l := utf8.DecodeRuneInString(s)
+
+	if r2, size := utf8.DecodeRuneInString(s[l:]); isRegionalIndicator(r) && isRegionalIndicator(r2) { // flag
+		return l + size
+	}
+
+	for l < len(s) {
+		r, size := utf8.DecodeRuneInString(s[l:])
+
+		switch {
+		case r == 0xFE0F || r == 0xFE0E || r == 0x20E3 || // variation selectors, keycap
+			(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tones
+			(r >= 0xE0020 && r <= 0xE007F): // tags
+			l += size
+		case r == 0x200D && isEmoji(next(s[l+size:])): // zero width joiner
+			_, joined := utf8.DecodeRuneInString(s[l+size:])
+			l += size + joined
+		default:
+			return l
+		}
+	}
+
+	return l
+}
+
+// SetTokenizer sets the Tokenizer FindAllMembersText splits text with
+// on this node (normally the root), nil restores the default SocialTokenizer
+// Phrases should be split into words the same way, NewPhraseTrieWith and
+// LoadLexiconWith build a Trie with a given Tokenizer
+func (n *PhraseTrie[V]) SetTokenizer(tokenizer Tokenizer) {
+	n.setConfig().tokenizer = tokenizer
+}
+
+// FindAllMembersText splits the text into a sentence with this Trie's
+// Tokenizer, see SetTokenizer, and finds all its member phrases
+// like FindAllMembers
+//
+// The contexts also hold the byte and rune offsets of each phrase in the
+// text, e.g. to highlight it. Offsets come from the spans of a SpanTokenizer,
+// with any other Tokenizer they are -1
+func (n *PhraseTrie[V]) FindAllMembersText(text string) PCtxListOf[V] {
+	var (
+		tokens []string
+		spans  []tokenSpan
+	)
+
+	if tokenizer, ok := n.tokenizer().(SpanTokenizer); ok {
+		var bytes [][2]int
+		tokens, bytes = tokenizer.TokenizeSpans(text)
+		spans = runeSpans(text, bytes)
+	} else {
+		tokens = n.tokenizer().Tokenize(text)
+	}
+
+	foundMembers := n.FindAllMembers(tokens)
+	for _, pc := range foundMembers {
+		if spans == nil {
+			pc.ByteOffsets = []int{-1, -1}
+			pc.RuneOffsets = []int{-1, -1}
+			continue
+		}
+
+		first, last := spans[pc.Indices[0]], spans[pc.Indices[1]]
+		pc.ByteOffsets = []int{first.bytes[0], last.bytes[1]}
+		pc.RuneOffsets = []int{first.runes[0], last.runes[1]}
+	}
+
+	return foundMembers
+}
+
+// tokenSpan is where a token is in a text, as [start, end) byte and rune offsets
+type tokenSpan struct {
+	bytes [2]int
+	runes [2]int
+}
+
+// runeSpans adds the rune offsets to the byte offsets of tokens in the text,
+// counting runes from the end of the previous token
+func runeSpans(text string, bytes [][2]int) []tokenSpan {
+	spans := make([]tokenSpan, len(bytes))
+
+	pos, runes := 0, 0 // byte and rune offsets counted up to
+	for i, b := range bytes {
+		if b[0] < pos { // out of order, count again from the start
+			pos, runes = 0, 0
+		}
+
+		start := runes + utf8.RuneCountInString(text[pos:b[0]])
+		end := start + utf8.RuneCountInString(text[b[0]:b[1]])
+
+		spans[i] = tokenSpan{bytes: b, runes: [2]int{start, end}}
+		pos, runes = b[1], end
+	}
+
+	return spans
+}
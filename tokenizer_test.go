@@ -0,0 +1,190 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocialTokenizer(t *testing.T) {
+	tokenize := defaultTokenizer.Tokenize
+
+	assert.Equal(t, []string{"will", "break", "out", "nicely", "!"}, tokenize("will  break out\tnicely!"))
+	assert.Equal(t, []string{"don't", "short-term", "r/g", "u.s", "."}, tokenize("don't short-term r/g u.s."))
+	assert.Equal(t, []string{"up", "1,000", "to", "1.5", "%"}, tokenize("up 1,000 to 1.5%"))
+	assert.Equal(t, []string{"wow", "!!!", "...", "ok", "?"}, tokenize("wow!!! ... ok?"))
+	assert.Equal(t, []string{"'", "quoted", "'", ",", "(", "paren", ")"}, tokenize("'quoted', (paren)"))
+
+	// cashtags and amounts
+	assert.Equal(t, []string{"$AAPL", "and", "$BRK.B", "at", "$100", "or", "$1.5k", "."}, tokenize("$AAPL and $BRK.B at $100 or $1.5k."))
+	assert.Equal(t, []string{"$AAPL", "'", "s", "$"}, tokenize("$AAPL's $"))
+
+	// mentions and hashtags
+	assert.Equal(t, []string{"@trader_joe", ":", "#bullish", "#1", "#", "!"}, tokenize("@trader_joe: #bullish #1 # !"))
+
+	// URLs
+	assert.Equal(t, []string{"see", "https://example.com/a?b=c", "."}, tokenize("see https://example.com/a?b=c."))
+	assert.Equal(t, []string{"(", "www.example.com", ")"}, tokenize("(www.example.com)"))
+	assert.Equal(t, []string{"http", ":", "//"}, tokenize("http://"))
+
+	// emoji
+	assert.Equal(t, []string{"to", "the", "moon", "🚀", "🚀", "🌕"}, tokenize("to the moon🚀🚀 🌕"))
+	assert.Equal(t, []string{"👍🏽", "❤️", "🇺🇸", "👨‍👩‍👧"}, tokenize("👍🏽❤️🇺🇸👨‍👩‍👧"))
+
+	// wildcards
+	assert.Equal(t, []string{"short", "**", "today", "break", "*", "resistance"}, tokenize("short ** today break * resistance"))
+
+	assert.Nil(t, tokenize(""))
+	assert.Nil(t, tokenize("  \n "))
+}
+
+func TestWhitespaceTokenizer(t *testing.T) {
+	assert.Equal(t, []string{"break", "out", "nicely!"}, WhitespaceTokenizer.Tokenize(" break  out nicely! "))
+
+	tokens, spans := WhitespaceTokenizer.(SpanTokenizer).TokenizeSpans(" break\t out nicely!")
+	assert.Equal(t, []string{"break", "out", "nicely!"}, tokens)
+	assert.Equal(t, [][2]int{{1, 6}, {8, 11}, {12, 19}}, spans)
+}
+
+// lowerSpanTokenizer is a SocialTokenizer that lowercases its tokens
+type lowerSpanTokenizer struct{}
+
+func (lowerSpanTokenizer) Tokenize(text string) []string {
+	tokens, _ := lowerSpanTokenizer{}.TokenizeSpans(text)
+	return tokens
+}
+
+func (lowerSpanTokenizer) TokenizeSpans(text string) ([]string, [][2]int) {
+	tokens, spans := SocialTokenizer{}.TokenizeSpans(text)
+	for i := range tokens {
+		tokens[i] = strings.ToLower(tokens[i])
+	}
+
+	return tokens, spans
+}
+
+func TestFindAllMembersText(t *testing.T) {
+	trie := mockTrieFull()
+
+	phrases := trie.FindAllMembersText("its shooting up!! i bet $AAPL will break  out nicely... 🚀 r/g")
+	assert.Equal(t, 3, len(phrases))
+	assert.Equal(t, []string{"shooting", "up"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrases[1].Phrase)
+	assert.Equal(t, []int{8, 10}, phrases[1].Indices)
+	assert.Equal(t, 6, phrases[1].Value)
+	assert.Equal(t, "its shooting up !! i bet $AAPL will break out nicely ... 🚀 r/g", phrases[1].SentenceStr())
+	assert.Equal(t, []string{"r/g"}, phrases[2].Phrase)
+
+	// with a Normalizer
+	trie.SetNormalizer(Lowercase)
+	phrases = trie.FindAllMembersText("Break Out!")
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, []string{"Break", "Out"}, phrases[0].Phrase)
+
+	// custom Tokenizer
+	trie.SetTokenizer(WhitespaceTokenizer)
+	phrases = trie.FindAllMembersText("break out nicely!")
+	assert.Equal(t, 1, len(phrases))
+	assert.Equal(t, []string{"break", "out"}, phrases[0].Phrase)
+
+	trie.SetTokenizer(nil)
+	phrases = trie.FindAllMembersText("break out nicely!")
+	assert.Equal(t, []string{"break", "out", "nicely"}, phrases[0].Phrase)
+
+	// keys are tokenized
+	trie = NewPhraseTrie(map[string]int{"break  out": 3, "nicely!": 6})
+	member, _ := trie.IsMember([]string{"break", "out"})
+	assert.True(t, member)
+	member, _ = trie.IsMember([]string{"nicely", "!"})
+	assert.True(t, member)
+
+	// punctuation in keys is split off
+	trie = NewPhraseTrie(map[string]int{":)": 1, "10%": 2})
+	assert.Equal(t, 0, len(trie.FindAllMembers([]string{":)", "10%"})))
+	member, _ = trie.IsMember([]string{"10", "%"})
+	assert.True(t, member)
+	assert.Equal(t, 2, len(trie.FindAllMembersText("up 10% :)")))
+
+	// keys are tokenized with the given Tokenizer
+	trie = NewPhraseTrieWith(map[string]int{":)": 1, "+1": 2}, WhitespaceTokenizer)
+	member, _ = trie.IsMember([]string{":)"})
+	assert.True(t, member)
+
+	phrases = trie.FindAllMembersText("great :) +1")
+	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, []string{"+1"}, phrases[1].Phrase)
+}
+
+func TestFindAllMembersTextOffsets(t *testing.T) {
+	trie := mockTrieFull()
+	text := "¡Wow! $AAPL will break  out nicely… 🚀 shooting up"
+
+	phrases := trie.FindAllMembersText(text)
+	assert.Equal(t, 2, len(phrases))
+
+	// word indices work as before
+	assert.Equal(t, []int{5, 7}, phrases[0].Indices)
+	assert.Equal(t, []int{10, 11}, phrases[1].Indices)
+
+	start := strings.Index(text, "break")
+	assert.Equal(t, []int{start, start + len("break  out nicely")}, phrases[0].ByteOffsets)
+	assert.Equal(t, "break  out nicely", text[phrases[0].ByteOffsets[0]:phrases[0].ByteOffsets[1]])
+	assert.Equal(t, "shooting up", text[phrases[1].ByteOffsets[0]:phrases[1].ByteOffsets[1]])
+
+	runes := []rune(text)
+	assert.Equal(t, []int{17, 34}, phrases[0].RuneOffsets)
+	assert.Equal(t, "break  out nicely", string(runes[phrases[0].RuneOffsets[0]:phrases[0].RuneOffsets[1]]))
+	assert.Equal(t, "shooting up", string(runes[phrases[1].RuneOffsets[0]:phrases[1].RuneOffsets[1]]))
+
+	// with a Normalizer the offsets point at the original words
+	trie.SetNormalizer(Lowercase)
+	phrases = trie.FindAllMembersText("so... BREAK OUT")
+	assert.Equal(t, []int{6, 15}, phrases[0].ByteOffsets)
+	assert.Equal(t, []int{6, 15}, phrases[0].RuneOffsets)
+
+	// a Tokenizer without spans has no offsets
+	trie = NewPhraseTrie(map[string]int{"up": 1})
+	trie.SetTokenizer(TokenizerFunc(func(text string) []string {
+		return strings.Fields(strings.ToLower(text))
+	}))
+	phrases = trie.FindAllMembersText("Up and up")
+	assert.Equal(t, 2, len(phrases))
+	assert.Equal(t, []int{-1, -1}, phrases[0].ByteOffsets)
+	assert.Equal(t, []int{-1, -1}, phrases[1].RuneOffsets)
+
+	// a SpanTokenizer gives exact offsets even for changed tokens
+	trie.SetTokenizer(lowerSpanTokenizer{})
+	phrases = trie.FindAllMembersText("Up and up")
+	assert.Equal(t, []int{0, 2}, phrases[0].ByteOffsets)
+	assert.Equal(t, []int{7, 9}, phrases[1].ByteOffsets)
+	assert.Equal(t, []int{7, 9}, phrases[1].RuneOffsets)
+
+	trie.SetTokenizer(WhitespaceTokenizer)
+	phrases = trie.FindAllMembersText("é  up")
+	assert.Equal(t, []int{4, 6}, phrases[0].ByteOffsets)
+	assert.Equal(t, []int{3, 5}, phrases[0].RuneOffsets)
+
+	// other lookups have no offsets
+	assert.Nil(t, trie.FindAllMembers([]string{"up"})[0].ByteOffsets)
+}
+
+func TestRuneSpans(t *testing.T) {
+	spans := runeSpans("é up up", [][2]int{{0, 2}, {3, 5}, {6, 8}, {0, 2}})
+
+	assert.Equal(t, [2]int{0, 1}, spans[0].runes)
+	assert.Equal(t, [2]int{2, 4}, spans[1].runes)
+	assert.Equal(t, [2]int{5, 7}, spans[2].runes)
+	assert.Equal(t, [2]int{0, 1}, spans[3].runes)
+	assert.Equal(t, [2]int{6, 8}, spans[2].bytes)
+}
+
+func BenchmarkSocialTokenizer(b *testing.B) {
+	text := strings.Repeat("its shooting up!! i bet $AAPL will break out nicely... 🚀 https://example.com @trader #bullish ", 10)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = defaultTokenizer.Tokenize(text)
+	}
+}
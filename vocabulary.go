@@ -0,0 +1,75 @@
+package trie
+
+// A TokenID is the integer id of a word interned in a Vocabulary
+type TokenID uint32
+
+// UnknownToken is the TokenID of words that are not in a Vocabulary
+// It is never interned, so it never matches a phrase word
+const UnknownToken TokenID = 0
+
+// A Vocabulary interns words to TokenIDs so phrases and sentences can be
+// stored and compared as integers instead of strings
+// Ids are assigned sequentially from 1 in the order words are interned
+type Vocabulary struct {
+	ids   map[string]TokenID
+	words []string
+}
+
+// An InternedSentence is a sentence with each of its words looked up in a Vocabulary
+// Words holds the original sentence and IDs the TokenID of each word
+type InternedSentence struct {
+	Words []string
+	IDs   []TokenID
+}
+
+// NewVocabulary creates a new empty Vocabulary
+func NewVocabulary() *Vocabulary {
+	return &Vocabulary{
+		ids:   make(map[string]TokenID),
+		words: []string{""}, // UnknownToken
+	}
+}
+
+// Intern returns the TokenID of the word, adding it to this Vocabulary if needed
+func (v *Vocabulary) Intern(word string) TokenID {
+	if id, ok := v.ids[word]; ok {
+		return id
+	}
+
+	id := TokenID(len(v.words))
+	v.ids[word] = id
+	v.words = append(v.words, word)
+
+	return id
+}
+
+// ID returns the TokenID of the word, or UnknownToken if it is not in this Vocabulary
+func (v *Vocabulary) ID(word string) TokenID {
+	return v.ids[word]
+}
+
+// Word returns the word of the TokenID, or the empty string if it is unknown
+func (v *Vocabulary) Word(id TokenID) string {
+	if int(id) >= len(v.words) {
+		return ""
+	}
+
+	return v.words[id]
+}
+
+// Len returns the number of words in this Vocabulary
+func (v *Vocabulary) Len() int {
+	return len(v.words) - 1
+}
+
+// InternSentence looks up every word of the sentence in this Vocabulary
+// Words that are not in the Vocabulary are not added, they get UnknownToken,
+// so interning arbitrary sentences does not grow the Vocabulary
+func (v *Vocabulary) InternSentence(sentence []string) *InternedSentence {
+	ids := make([]TokenID, len(sentence))
+	for i, word := range sentence {
+		ids[i] = v.ids[word]
+	}
+
+	return &InternedSentence{Words: sentence, IDs: ids}
+}
@@ -0,0 +1,47 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVocabulary(t *testing.T) {
+	vocab := NewVocabulary()
+	assert.Equal(t, 0, vocab.Len())
+
+	// unknown
+	assert.Equal(t, UnknownToken, vocab.ID("break"))
+	assert.Equal(t, "", vocab.Word(UnknownToken))
+	assert.Equal(t, "", vocab.Word(42))
+
+	// sequential ids from 1
+	assert.Equal(t, TokenID(1), vocab.Intern("break"))
+	assert.Equal(t, TokenID(2), vocab.Intern("out"))
+	assert.Equal(t, TokenID(1), vocab.Intern("break"))
+	assert.Equal(t, 2, vocab.Len())
+
+	assert.Equal(t, TokenID(2), vocab.ID("out"))
+	assert.Equal(t, "break", vocab.Word(1))
+	assert.Equal(t, "out", vocab.Word(2))
+
+	// empty string is a word like any other
+	assert.Equal(t, TokenID(3), vocab.Intern(""))
+	assert.Equal(t, TokenID(3), vocab.ID(""))
+}
+
+func TestInternSentence(t *testing.T) {
+	vocab := NewVocabulary()
+	vocab.Intern("break")
+	vocab.Intern("out")
+
+	sentence := strings.Split("$AAPL will break out", " ")
+	s := vocab.InternSentence(sentence)
+
+	assert.Equal(t, sentence, s.Words)
+	assert.Equal(t, []TokenID{UnknownToken, UnknownToken, 1, 2}, s.IDs)
+
+	// unknown words are not added
+	assert.Equal(t, 2, vocab.Len())
+}
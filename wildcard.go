@@ -0,0 +1,130 @@
+package trie
+
+/* WILDCARD AND GAP TOLERANT MATCHING */
+
+const (
+	// WildcardWord is a phrase word that matches exactly one arbitrary word,
+	// e.g. "break * resistance" matches "break the resistance"
+	WildcardWord = "*"
+
+	// WildcardWords is a phrase word that matches any number of arbitrary
+	// words up to the wildcard cap, including none, e.g. "short ** today"
+	// matches "short $AAPL today" and "short it hard today"
+	WildcardWords = "**"
+
+	// DefaultWildcardCap is the default maximum number of words a
+	// WildcardWords matches, see SetWildcardCap
+	DefaultWildcardCap = 4
+)
+
+// SetWildcardCap sets the maximum number of words a WildcardWords phrase word
+// matches in lookups started from this node (normally the root)
+// A cap less than 1 restores DefaultWildcardCap
+func (n *PhraseTrie[V]) SetWildcardCap(words int) {
+	if words < 1 {
+		words = 0
+	}
+
+	n.setConfig().wildcardCap = int32(words)
+}
+
+// isWildcard returns true if the key is a wildcard phrase word
+func isWildcard(key string) bool {
+	return key == WildcardWord || key == WildcardWords
+}
+
+// containsWildcards returns true if any node below this node is a wildcard
+func (n *PhraseTrie[V]) containsWildcards() bool {
+	for _, child := range n.children {
+		if isWildcard(child.key) || child.containsWildcards() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchWildcards is matchPrefixes for a Trie holding wildcards
+func (n *PhraseTrie[V]) matchWildcards(sequence []string, fn func(length int, end *PhraseTrie[V], pattern []string)) {
+	n.search(sequence, 0, func(end *PhraseTrie[V], positions []int, pattern []string) {
+		fn(positions[len(positions)-1]+1, end, pattern)
+	})
+}
+
+// search follows the sequence down this Trie through any wildcards,
+// skipping up to maxSkip words of the sequence before each phrase word
+// but the first, and calls fn with the end node and the positions of the
+// matched words of every member phrase the sequence begins with, and with
+// the stored phrase if the path went through a wildcard
+// Paths are searched depth first, so a path with skips may be found before
+// one without, positions and pattern are only valid until fn returns
+func (n *PhraseTrie[V]) search(sequence []string, maxSkip int, fn func(end *PhraseTrie[V], positions []int, pattern []string)) {
+	config := n.config()
+
+	span := DefaultWildcardCap
+	if config.wildcardCap != 0 {
+		span = int(config.wildcardCap)
+	}
+
+	var (
+		positions []int
+		pattern   []string
+		wild      int // wildcards in pattern
+		from      func(node *PhraseTrie[V], pos int)
+	)
+
+	// visit enters child after it matched the words from start up to pos
+	visit := func(child *PhraseTrie[V], start, pos int) {
+		mark := len(positions)
+		for p := start; p < pos; p++ {
+			positions = append(positions, p)
+		}
+
+		pattern = append(pattern, child.key)
+		if isWildcard(child.key) {
+			wild++
+		}
+
+		if len(positions) != 0 && child.endsPhrase(config.leafOnly) {
+			if wild != 0 {
+				fn(child, positions, pattern)
+			} else {
+				fn(child, positions, nil)
+			}
+		}
+
+		from(child, pos)
+
+		positions = positions[:mark]
+		pattern = pattern[:len(pattern)-1]
+		if isWildcard(child.key) {
+			wild--
+		}
+	}
+
+	// from follows the sequence from pos down the children of node
+	from = func(node *PhraseTrie[V], pos int) {
+		skips := maxSkip
+		if node == n { // the sequence begins with the phrase
+			skips = 0
+		}
+
+		for skip := 0; skip <= skips && pos+skip < len(sequence); skip++ {
+			if child := node.child(sequence[pos+skip]); child != nil && !isWildcard(child.key) {
+				visit(child, pos+skip, pos+skip+1)
+			}
+		}
+
+		if child := node.child(WildcardWord); child != nil && pos < len(sequence) {
+			visit(child, pos, pos+1)
+		}
+
+		if child := node.child(WildcardWords); child != nil {
+			for words := 0; words <= span && pos+words <= len(sequence); words++ {
+				visit(child, pos, pos+words)
+			}
+		}
+	}
+
+	from(n, 0)
+}
@@ -0,0 +1,162 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockTrieWildcards() *PhraseTrieNode {
+	m := map[string]int{
+		"break out":            3,
+		"break * resistance":   4,
+		"break out resistance": 5,
+		"short ** today":       -6,
+		"** squeeze":           7,
+	}
+
+	return NewPhraseTrie(m)
+}
+
+func TestWildcardWord(t *testing.T) {
+	trie := mockTrieWildcards()
+
+	valid, phrase, value := trie.FindMember(strings.Split("break the resistance now", " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "the", "resistance"}, phrase)
+	assert.Equal(t, 4, value)
+
+	// exactly one word
+	valid, _, _ = trie.FindMember(strings.Split("break resistance", " "))
+	assert.False(t, valid)
+
+	valid, phrase, value = trie.FindMember(strings.Split("break through the resistance", " "))
+	assert.False(t, valid)
+	assert.Equal(t, []string{}, phrase)
+	assert.Equal(t, 0, value)
+
+	// exact phrase wins a tie
+	valid, phrase, value = trie.FindMember(strings.Split("break out resistance", " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out", "resistance"}, phrase)
+	assert.Equal(t, 5, value)
+
+	// backtracks to the longest match
+	valid, phrase, value = trie.FindMember(strings.Split("break out today", " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"break", "out"}, phrase)
+	assert.Equal(t, 3, value)
+
+	// stored phrases are members literally
+	member, value := trie.IsMember([]string{"break", "*", "resistance"})
+	assert.True(t, member)
+	assert.Equal(t, 4, value)
+
+	member, _ = trie.IsMember([]string{"break", "the", "resistance"})
+	assert.False(t, member)
+}
+
+func TestWildcardWords(t *testing.T) {
+	trie := mockTrieWildcards()
+
+	for _, sentence := range []string{
+		"short today",
+		"short $AAPL today",
+		"short it hard today",
+		"short it really very hard today",
+	} {
+		valid, phrase, value := trie.FindMember(strings.Split(sentence, " "))
+		assert.True(t, valid, sentence)
+		assert.Equal(t, strings.Split(sentence, " "), phrase)
+		assert.Equal(t, -6, value)
+	}
+
+	// over the default cap
+	valid, _, _ := trie.FindMember(strings.Split("short it really very very hard today", " "))
+	assert.False(t, valid)
+
+	trie.SetWildcardCap(5)
+	valid, _, _ = trie.FindMember(strings.Split("short it really very very hard today", " "))
+	assert.True(t, valid)
+
+	trie.SetWildcardCap(1)
+	valid, _, _ = trie.FindMember(strings.Split("short it hard today", " "))
+	assert.False(t, valid)
+	valid, _, _ = trie.FindMember(strings.Split("short it today", " "))
+	assert.True(t, valid)
+
+	trie.SetWildcardCap(0)
+	valid, _, _ = trie.FindMember(strings.Split("short it really very hard today", " "))
+	assert.True(t, valid)
+
+	// leading wildcard
+	valid, phrase, value := trie.FindMember(strings.Split("massive short squeeze", " "))
+	assert.True(t, valid)
+	assert.Equal(t, []string{"massive", "short", "squeeze"}, phrase)
+	assert.Equal(t, 7, value)
+}
+
+func TestWildcardFindAllMembers(t *testing.T) {
+	trie := mockTrieWildcards()
+	sentence := strings.Split("they break the resistance then short $AAPL today", " ")
+
+	phrases := trie.FindAllMembers(sentence)
+	assert.Equal(t, 2, len(phrases))
+
+	assert.Equal(t, []string{"break", "the", "resistance"}, phrases[0].Phrase)
+	assert.Equal(t, []string{"break", "*", "resistance"}, phrases[0].Pattern)
+	assert.Equal(t, []int{1, 3}, phrases[0].Indices)
+	assert.Equal(t, 4, phrases[0].Value)
+	assert.Equal(t, sentence, phrases[0].Sentence)
+
+	assert.Equal(t, []string{"short", "$AAPL", "today"}, phrases[1].Phrase)
+	assert.Equal(t, []string{"short", "**", "today"}, phrases[1].Pattern)
+	assert.Equal(t, []int{5, 7}, phrases[1].Indices)
+	assert.Equal(t, -6, phrases[1].Value)
+
+	// exact matches have no pattern
+	phrases = trie.FindAllMembers(strings.Split("break out now", " "))
+	assert.Equal(t, 1, len(phrases))
+	assert.Nil(t, phrases[0].Pattern)
+}
+
+func TestWildcardFindMembersAt(t *testing.T) {
+	trie := mockTrieWildcards()
+
+	phrases := trie.FindMembersAt(strings.Split("break out resistance squeeze", " "))
+	assert.Equal(t, 4, len(phrases))
+
+	// shortest to longest, exact paths first
+	assert.Equal(t, []string{"break", "out"}, phrases[0].Phrase)
+	assert.Nil(t, phrases[0].Pattern)
+	assert.Equal(t, []string{"break", "out", "resistance"}, phrases[1].Phrase)
+	assert.Nil(t, phrases[1].Pattern)
+	assert.Equal(t, []string{"break", "*", "resistance"}, phrases[2].Pattern)
+	assert.Equal(t, []string{"**", "squeeze"}, phrases[3].Pattern)
+	assert.Equal(t, []string{"break", "out", "resistance", "squeeze"}, phrases[3].Phrase)
+	assert.Equal(t, []int{0, 3}, phrases[3].Indices)
+}
+
+func TestWildcardBinary(t *testing.T) {
+	data, err := mockTrieWildcards().MarshalBinary()
+	assert.Nil(t, err)
+
+	trie := NewPhraseTrie(nil)
+	assert.Nil(t, trie.UnmarshalBinary(data))
+
+	valid, _, value := trie.FindMember(strings.Split("break the resistance", " "))
+	assert.True(t, valid)
+	assert.Equal(t, 4, value)
+}
+
+func BenchmarkWildcardFindAllMembers(b *testing.B) {
+	trie := mockTrieWildcards()
+	sSplit := strings.Split("they break the resistance then short $AAPL today and break out in a massive squeeze", " ")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = trie.FindAllMembers(sSplit)
+	}
+}